@@ -3,8 +3,11 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/models"
 	dphttp "github.com/ONSdigital/dp-net/http"
 	"github.com/ONSdigital/log.go/v2/log"
@@ -26,18 +29,26 @@ func (api *ImportAPI) addJobHandler(w http.ResponseWriter, r *http.Request) {
 
 	logData := log.Data{"recipeID": job.RecipeID}
 
-	b, err := api.addJob(ctx, job, logData)
+	// the job is stored with the idempotency key until the retention window has passed
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		expiry := time.Now().UTC().Add(api.idempotencyKeyRetention)
+		job.IdempotencyKey = key
+		job.IdempotencyKeyExpiry = &expiry
+		logData["idempotency_key"] = key
+	}
+
+	b, status, err := api.addJob(ctx, job, logData)
 	if err != nil {
 		handleErr(ctx, w, err, logData)
 		return
 	}
 
-	writeResponse(ctx, w, http.StatusCreated, b, "addJob", logData)
+	writeResponse(ctx, w, status, b, "addJob", logData)
 	log.Info(ctx, "created new import job", logData)
 }
 
-func (api *ImportAPI) addJob(ctx context.Context, job *models.Job, logData log.Data) (b []byte, err error) {
-	createdJob, err := api.jobService.CreateJob(ctx, job)
+func (api *ImportAPI) addJob(ctx context.Context, job *models.Job, logData log.Data) (b []byte, status int, err error) {
+	createdJob, status, err := api.createJob(ctx, job, logData)
 	if err != nil {
 		log.Error(ctx, "addJob endpoint: error creating job resource", err, logData)
 		return
@@ -48,8 +59,39 @@ func (api *ImportAPI) addJob(ctx context.Context, job *models.Job, logData log.D
 	b, err = json.Marshal(createdJob)
 	if err != nil {
 		log.Error(ctx, "addJob endpoint: failed to marshal job resource into bytes", err, logData)
-		return nil, err
+		return nil, 0, err
 	}
 
 	return
 }
+
+// createJob creates the provided job and returns it with a 201 status. If a job has already been created with the
+// idempotency key of the provided job, and the key has not expired, that job is returned with a 200 status instead.
+func (api *ImportAPI) createJob(ctx context.Context, job *models.Job, logData log.Data) (*models.Job, int, error) {
+	if job.IdempotencyKey != "" {
+		existingJob, err := api.dataStore.GetJobByIdempotencyKey(ctx, job.IdempotencyKey)
+		if err == nil {
+			log.Info(ctx, "addJob endpoint: job already created with the idempotency key", logData)
+			return existingJob, http.StatusOK, nil
+		}
+		if !errors.Is(err, errs.ErrJobNotFound) {
+			return nil, 0, err
+		}
+	}
+
+	createdJob, err := api.jobService.CreateJob(ctx, job)
+	if errors.Is(err, errs.ErrDuplicateIdempotencyKey) {
+		// a concurrent request with the same idempotency key has created the job first
+		existingJob, getErr := api.dataStore.GetJobByIdempotencyKey(ctx, job.IdempotencyKey)
+		if getErr != nil {
+			return nil, 0, err
+		}
+		log.Info(ctx, "addJob endpoint: job already created with the idempotency key", logData)
+		return existingJob, http.StatusOK, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return createdJob, http.StatusCreated, nil
+}
@@ -8,10 +8,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-import-api/api/testapi"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
 	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/gorilla/mux"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -171,3 +174,106 @@ func TestSuccessfullyAddJob(t *testing.T) {
 		})
 	})
 }
+
+func TestAddJobWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to add a job with an idempotency key", t, func() {
+		existingJob := &models.Job{ID: "existing-job"}
+		mockJobService := &testapi.JobServiceMock{
+			CreateJobFunc: func(ctx context.Context, job *models.Job) (*models.Job, error) {
+				return dummyJob, nil
+			},
+		}
+
+		newRequest := func() *http.Request {
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs", strings.NewReader(`{"recipe":"test"}`))
+			So(err, ShouldBeNil)
+			r.Header.Set("Idempotency-Key", "key-123")
+			return r
+		}
+
+		Convey("When no job has been created with the key", func() {
+			mockDataStore := &dsmock.DataStorerMock{
+				GetJobByIdempotencyKeyFunc: func(ctx context.Context, key string) (*models.Job, error) {
+					return nil, errs.ErrJobNotFound
+				},
+			}
+			api := Setup(mux.NewRouter(), mockDataStore, mockJobService, cfg)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, newRequest())
+
+			Convey("Then the job is created with the key and its expiry, and status created (201) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusCreated)
+				So(w.Body.String(), ShouldContainSubstring, `"id":"34534543543"`)
+				So(mockJobService.CreateJobCalls(), ShouldHaveLength, 1)
+				job := mockJobService.CreateJobCalls()[0].Job
+				So(job.IdempotencyKey, ShouldEqual, "key-123")
+				So(*job.IdempotencyKeyExpiry, ShouldHappenWithin, time.Minute, time.Now().Add(cfg.IdempotencyKeyRetention))
+			})
+		})
+
+		Convey("When a job has already been created with the key", func() {
+			mockDataStore := &dsmock.DataStorerMock{
+				GetJobByIdempotencyKeyFunc: func(ctx context.Context, key string) (*models.Job, error) {
+					return existingJob, nil
+				},
+			}
+			api := Setup(mux.NewRouter(), mockDataStore, mockJobService, cfg)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, newRequest())
+
+			Convey("Then the existing job is returned with status ok (200) and no job is created", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldContainSubstring, `"id":"existing-job"`)
+				So(mockDataStore.GetJobByIdempotencyKeyCalls()[0].Key, ShouldEqual, "key-123")
+				So(mockJobService.CreateJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a concurrent request creates a job with the key first", func() {
+			calls := 0
+			mockDataStore := &dsmock.DataStorerMock{
+				GetJobByIdempotencyKeyFunc: func(ctx context.Context, key string) (*models.Job, error) {
+					calls++
+					if calls == 1 {
+						return nil, errs.ErrJobNotFound
+					}
+					return existingJob, nil
+				},
+			}
+			mockJobService.CreateJobFunc = func(ctx context.Context, job *models.Job) (*models.Job, error) {
+				return nil, errs.ErrDuplicateIdempotencyKey
+			}
+			api := Setup(mux.NewRouter(), mockDataStore, mockJobService, cfg)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, newRequest())
+
+			Convey("Then the job created by the concurrent request is returned with status ok (200)", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldContainSubstring, `"id":"existing-job"`)
+				So(mockDataStore.GetJobByIdempotencyKeyCalls(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the datastore fails to look up the key", func() {
+			mockDataStore := &dsmock.DataStorerMock{
+				GetJobByIdempotencyKeyFunc: func(ctx context.Context, key string) (*models.Job, error) {
+					return nil, errs.ErrInternalServer
+				},
+			}
+			api := Setup(mux.NewRouter(), mockDataStore, mockJobService, cfg)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, newRequest())
+
+			Convey("Then status internal server error (500) is returned and no job is created", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				So(mockJobService.CreateJobCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -28,7 +28,7 @@ func (api *ImportAPI) addUploadedFileHandler(w http.ResponseWriter, r *http.Requ
 
 	logData["file"] = uploadedFile
 
-	if err := api.addUploadFile(ctx, uploadedFile, jobID, logData); err != nil {
+	if err := api.addUploadFile(ctx, uploadedFile, jobID, getIfMatch(r), logData); err != nil {
 		handleErr(ctx, w, err, logData)
 		return
 	}
@@ -36,9 +36,9 @@ func (api *ImportAPI) addUploadedFileHandler(w http.ResponseWriter, r *http.Requ
 	log.Info(ctx, "added uploaded file to job", logData)
 }
 
-func (api *ImportAPI) addUploadFile(ctx context.Context, uploadedFile *models.UploadedFile, jobID string, logData log.Data) (err error) {
+func (api *ImportAPI) addUploadFile(ctx context.Context, uploadedFile *models.UploadedFile, jobID, eTag string, logData log.Data) (err error) {
 
-	if err = api.dataStore.AddUploadedFile(ctx, jobID, uploadedFile); err != nil {
+	if err = api.dataStore.AddUploadedFile(ctx, jobID, uploadedFile, eTag); err != nil {
 		log.Error(ctx, "addUploadFile endpoint: failed to store uploaded file resource", err, logData)
 	}
 
@@ -2,15 +2,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
 	"github.com/ONSdigital/dp-import-api/config"
 
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/datastore"
 	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/dp-import-api/url"
 	"github.com/ONSdigital/dp-net/handlers"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 )
@@ -20,6 +25,9 @@ import (
 const (
 	jobIDKey      = "job_id"
 	instanceIDKey = "instance_id"
+	aliasNameKey  = "alias_name"
+
+	idempotencyKeyHeader = "Idempotency-Key"
 )
 
 // ImportAPI is a restful API used to manage importing datasets to be published
@@ -27,15 +35,33 @@ type ImportAPI struct {
 	dataStore     datastore.DataStorer
 	router        *mux.Router
 	jobService    JobService
+	urlBuilder    *url.Builder
 	defaultLimit  int
 	defaultOffset int
 	maxLimit      int
+
+	idempotencyKeyRetention time.Duration
 }
 
 // JobService provide business logic for job related operations.
 type JobService interface {
 	CreateJob(ctx context.Context, job *models.Job) (*models.Job, error)
-	UpdateJob(ctx context.Context, jobID string, job *models.Job) error
+	UpdateJob(ctx context.Context, jobID string, job *models.Job, eTag string) error
+	PatchJob(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error
+	GetJobReadiness(ctx context.Context, jobID string) (*models.Readiness, error)
+	CancelJob(ctx context.Context, jobID string) error
+	RetryJob(ctx context.Context, jobID string, options *models.RetryOptions) error
+	Formats() []string
+	IncreaseProcessedInstance(ctx context.Context, jobID, instanceID, dimension, eTag string) ([]models.ProcessedInstances, error)
+}
+
+// getIfMatch returns the value of the If-Match header of the provided request, or AnyETag if it is not provided
+func getIfMatch(r *http.Request) string {
+	eTag, err := headers.GetIfMatch(r)
+	if err != nil || eTag == "" {
+		return models.AnyETag
+	}
+	return eTag
 }
 
 // Setup manages all the routes configured to API
@@ -47,17 +73,26 @@ func Setup(router *mux.Router,
 		dataStore:     dataStore,
 		router:        router,
 		jobService:    jobService,
+		urlBuilder:    url.NewBuilder(cfg.Host, cfg.DatasetAPIURL),
 		defaultLimit:  cfg.DefaultLimit,
 		defaultOffset: cfg.DefaultOffset,
 		maxLimit:      cfg.DefaultMaxLimit,
+
+		idempotencyKeyRetention: cfg.IdempotencyKeyRetention,
 	}
 
 	// External API for florence
+	api.router.Path("/formats").Methods("GET").HandlerFunc(handlers.CheckIdentity(api.getFormatsHandler))
 	api.router.Path("/jobs").Methods("POST").HandlerFunc(handlers.CheckIdentity(api.addJobHandler))
 	api.router.Path("/jobs").Methods("GET").HandlerFunc(handlers.CheckIdentity(api.getJobsHandler))
 	api.router.Path("/jobs/{id}").Methods("GET").HandlerFunc(handlers.CheckIdentity(api.getJobHandler))
 	api.router.Path("/jobs/{id}").Methods("PUT").HandlerFunc(handlers.CheckIdentity(api.updateJobHandler))
+	api.router.Path("/jobs/{id}").Methods("PATCH").HandlerFunc(handlers.CheckIdentity(api.patchJobHandler))
+	api.router.Path("/jobs/{id}/readiness").Methods("GET").HandlerFunc(handlers.CheckIdentity(api.getJobReadinessHandler))
+	api.router.Path("/jobs/{id}/cancel").Methods("POST").HandlerFunc(handlers.CheckIdentity(api.cancelJobHandler))
+	api.router.Path("/jobs/{id}/retry").Methods("POST").HandlerFunc(handlers.CheckIdentity(api.retryJobHandler))
 	api.router.Path("/jobs/{id}/files").Methods("PUT").HandlerFunc(handlers.CheckIdentity(api.addUploadedFileHandler))
+	api.router.Path("/jobs/{id}/files/{alias_name}").Methods("DELETE").HandlerFunc(handlers.CheckIdentity(api.deleteUploadedFileHandler))
 	api.router.Path("/jobs/{id}/processed/{instance_id}").Methods("PUT").HandlerFunc(handlers.CheckIdentity(api.increaseProcessedInstanceHandler))
 	return api
 }
@@ -79,10 +114,12 @@ func handleErr(ctx context.Context, w http.ResponseWriter, err error, logData lo
 	response := err
 
 	switch {
-	case errs.NotFoundMap[err]:
+	case matchesAny(errs.NotFoundMap, err):
 		status = http.StatusNotFound
-	case errs.BadRequestMap[err]:
+	case matchesAny(errs.BadRequestMap, err):
 		status = http.StatusBadRequest
+	case matchesAny(errs.ConflictMap, err):
+		status = http.StatusConflict
 	default:
 		status = http.StatusInternalServerError
 		response = errs.ErrInternalServer
@@ -92,6 +129,19 @@ func handleErr(ctx context.Context, w http.ResponseWriter, err error, logData lo
 	http.Error(w, response.Error(), status)
 }
 
+// matchesAny returns true if the provided error is, or wraps, any of the errors in the provided map
+func matchesAny(errMap map[error]bool, err error) bool {
+	if errMap[err] {
+		return true
+	}
+	for mapped := range errMap {
+		if errors.Is(err, mapped) {
+			return true
+		}
+	}
+	return false
+}
+
 func handleCustomErr(ctx context.Context, w http.ResponseWriter, err error, logData log.Data, status int) {
 	if logData == nil {
 		logData = log.Data{}
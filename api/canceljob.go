@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+func (api *ImportAPI) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer dphttp.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+	logData := log.Data{jobIDKey: jobID}
+
+	if err := api.jobService.CancelJob(ctx, jobID); err != nil {
+		log.Error(ctx, "cancelJob endpoint: failed to cancel job", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+	log.Info(ctx, "job cancelled successfully", logData)
+}
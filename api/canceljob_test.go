@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCancelJob(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to cancel a job", t, func() {
+		w := httptest.NewRecorder()
+
+		Convey("When request has no auth header", func() {
+			mockJobService := &testapi.JobServiceMock{}
+			api := SetupAPIWith(nil, mockJobService)
+
+			r, err := testapi.CreateRequestWithOutAuth("POST", "http://localhost:21800/jobs/12345/cancel", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status unauthorised (401)", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(mockJobService.CancelJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the job is successfully cancelled", func() {
+			mockJobService := &testapi.JobServiceMock{
+				CancelJobFunc: func(ctx context.Context, jobID string) error {
+					return nil
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/cancel", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status ok (200) and the job service is called with the job ID", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.CancelJobCalls(), ShouldHaveLength, 1)
+				So(mockJobService.CancelJobCalls()[0].JobID, ShouldEqual, "12345")
+			})
+
+			Convey("Then the request body has been drained", func() {
+				bytesRead, err := r.Body.Read(make([]byte, 1))
+				So(bytesRead, ShouldEqual, 0)
+				So(err, ShouldEqual, io.EOF)
+			})
+		})
+
+		Convey("When the job does not exist", func() {
+			mockJobService := &testapi.JobServiceMock{
+				CancelJobFunc: func(ctx context.Context, jobID string) error {
+					return errs.ErrJobNotFound
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/cancel", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status not found (404)", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotFound.Error())
+			})
+		})
+
+		Convey("When the job can no longer be cancelled", func() {
+			mockJobService := &testapi.JobServiceMock{
+				CancelJobFunc: func(ctx context.Context, jobID string) error {
+					return errs.ErrInvalidStateTransition
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/cancel", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status conflict (409)", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidStateTransition.Error())
+			})
+		})
+	})
+}
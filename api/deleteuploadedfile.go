@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+func (api *ImportAPI) deleteUploadedFileHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer dphttp.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+	aliasName := vars["alias_name"]
+	logData := log.Data{jobIDKey: jobID, aliasNameKey: aliasName}
+
+	if err := api.dataStore.DeleteUploadedFile(ctx, jobID, aliasName, getIfMatch(r)); err != nil {
+		log.Error(ctx, "deleteUploadedFile endpoint: failed to delete uploaded file resource", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Info(ctx, "deleted uploaded file from job", logData)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFailureToDeleteFile(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to delete an uploaded file", t, func() {
+		Convey("When no auth token is provided", func() {
+			Convey("Then return status unauthorised (401)", func() {
+				api := SetupAPIWith(nil, nil)
+
+				r, err := testapi.CreateRequestWithOutAuth("DELETE", "http://localhost:21800/jobs/12345/files/v4", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnauthorised.Error())
+			})
+		})
+
+		Convey("When the job does not exist", func() {
+			Convey("Then return status not found (404)", func() {
+				api := SetupAPIWith(&testapi.DstoreNotFound, nil)
+
+				r, err := testapi.CreateRequestWithAuth("DELETE", "http://localhost:21800/jobs/12345/files/v4", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotFound.Error())
+			})
+		})
+
+		Convey("When the job does not have a file with the alias name", func() {
+			Convey("Then return status not found (404)", func() {
+				mockDataStore := &dsmock.DataStorerMock{
+					DeleteUploadedFileFunc: func(ctx context.Context, jobID, aliasName, eTag string) error {
+						return errs.ErrUploadedFileNotFound
+					},
+				}
+				api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+				r, err := testapi.CreateRequestWithAuth("DELETE", "http://localhost:21800/jobs/12345/files/v4", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUploadedFileNotFound.Error())
+			})
+		})
+
+		Convey("When the job has been submitted", func() {
+			Convey("Then return status conflict (409)", func() {
+				mockDataStore := &dsmock.DataStorerMock{
+					DeleteUploadedFileFunc: func(ctx context.Context, jobID, aliasName, eTag string) error {
+						return errs.ErrJobFilesLocked
+					},
+				}
+				api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+				r, err := testapi.CreateRequestWithAuth("DELETE", "http://localhost:21800/jobs/12345/files/v4", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobFilesLocked.Error())
+			})
+		})
+	})
+}
+
+func TestSuccessfullyDeleteFile(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to delete an uploaded file with an If-Match header", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			DeleteUploadedFileFunc: func(ctx context.Context, jobID, aliasName, eTag string) error {
+				return nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		r, err := testapi.CreateRequestWithAuth("DELETE", "http://localhost:21800/jobs/12345/files/v4", nil)
+		So(err, ShouldBeNil)
+		r.Header.Set("If-Match", "1650000000-1")
+
+		Convey("When the file is deleted", func() {
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status no content (204)", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(mockDataStore.DeleteUploadedFileCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.DeleteUploadedFileCalls()[0].JobID, ShouldEqual, "12345")
+				So(mockDataStore.DeleteUploadedFileCalls()[0].AliasName, ShouldEqual, "v4")
+				So(mockDataStore.DeleteUploadedFileCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+		})
+	})
+}
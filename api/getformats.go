@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+func (api *ImportAPI) getFormatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logData := log.Data{}
+
+	formats := api.jobService.Formats()
+	logData["formats"] = formats
+
+	b, err := json.Marshal(&models.FormatResults{
+		Count: len(formats),
+		Items: formats,
+	})
+	if err != nil {
+		log.Error(ctx, "getFormats endpoint: failed to marshal formats resource into bytes", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	writeResponse(ctx, w, http.StatusOK, b, "getFormats", logData)
+	log.Info(ctx, "getFormats endpoint: request successful", logData)
+}
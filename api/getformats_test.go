@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetFormats(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get the supported formats", t, func() {
+		w := httptest.NewRecorder()
+		mockJobService := &testapi.JobServiceMock{
+			FormatsFunc: func() []string {
+				return []string{"cantabular_table", "v4"}
+			},
+		}
+		api := SetupAPIWith(nil, mockJobService)
+
+		Convey("When request has no auth header", func() {
+			r, err := testapi.CreateRequestWithOutAuth("GET", "http://localhost:21800/formats", nil)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status unauthorised (401)", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrUnauthorised.Error())
+				So(mockJobService.FormatsCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When request is authorised", func() {
+			r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/formats", nil)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status ok (200) with the formats supported by the job service", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"count":2,"items":["cantabular_table","v4"]}`)
+				So(mockJobService.FormatsCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
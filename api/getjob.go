@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 
+	"github.com/ONSdigital/dp-import-api/models"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 )
@@ -15,24 +17,34 @@ func (api *ImportAPI) getJobHandler(w http.ResponseWriter, r *http.Request) {
 	jobID := vars["id"]
 	logData := log.Data{jobIDKey: jobID}
 
-	b, err := api.getJob(ctx, jobID, logData)
+	fields, err := queryFields(r.URL.Query(), logData)
 	if err != nil {
+		log.Error(ctx, "invalid query parameter: fields", err, logData)
 		handleErr(ctx, w, err, logData)
 		return
 	}
 
+	b, eTag, err := api.getJob(ctx, jobID, fields, logData)
+	if err != nil {
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	w.Header().Set("ETag", eTag)
+
 	writeResponse(ctx, w, http.StatusOK, b, "getJob", logData)
 	log.Info(ctx, "getJob endpoint: request successful", logData)
 }
 
-func (api *ImportAPI) getJob(ctx context.Context, jobID string, logData log.Data) (b []byte, err error) {
-	job, err := api.dataStore.GetJob(ctx, jobID)
+func (api *ImportAPI) getJob(ctx context.Context, jobID string, fields []string, logData log.Data) (b []byte, eTag string, err error) {
+	job, err := api.dataStore.GetJobFields(ctx, jobID, fields)
 	if err != nil {
 		log.Error(ctx, "getJob endpoint: failed to find job", err, logData)
 		return
 	}
 
 	logData["job"] = job
+	eTag = job.ETag()
 
 	b, err = json.Marshal(job)
 	if err != nil {
@@ -40,3 +52,13 @@ func (api *ImportAPI) getJob(ctx context.Context, jobID string, logData log.Data
 	}
 	return
 }
+
+// queryFields returns the job fields requested by the fields query parameter, or nil if every field is requested
+func queryFields(query url.Values, logData log.Data) ([]string, error) {
+	value := query.Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+	logData["fields"] = value
+	return models.ParseJobFields(value)
+}
@@ -1,13 +1,18 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/ONSdigital/dp-import-api/api/testapi"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/gorilla/mux"
 	. "github.com/smartystreets/goconvey/convey"
+	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestFailureToGetJob(t *testing.T) {
@@ -43,6 +48,21 @@ func TestFailureToGetJob(t *testing.T) {
 				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotFound.Error())
 			})
 		})
+
+		Convey("When an unknown field is requested", func() {
+			Convey("Then return status bad request (400)", func() {
+				api := SetupAPIWith(nil, nil)
+
+				r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs/123?fields=state,idempotency_key", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, "unknown job field 'idempotency_key'")
+			})
+		})
 	})
 }
 
@@ -61,6 +81,37 @@ func TestSuccessfullyGetJob(t *testing.T) {
 				api.router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("ETag"), ShouldEqual, "0-0")
+			})
+		})
+	})
+}
+
+func TestGetJobWithFields(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get only some fields of a job", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFieldsFunc: func(ctx context.Context, jobID string, fields []string) (*models.Job, error) {
+				return &models.Job{ID: jobID, State: models.CreatedState, UniqueTimestamp: bsonprim.Timestamp{T: 1650000000, I: 1}}, nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs/123?fields=state,links", nil)
+		So(err, ShouldBeNil)
+
+		Convey("When the job is retrieved", func() {
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the fields are provided to the datastore, and the job is returned with its eTag", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobFieldsCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobFieldsCalls()[0].JobID, ShouldEqual, "123")
+				So(mockDataStore.GetJobFieldsCalls()[0].Fields, ShouldResemble, []string{"state", "links"})
+				So(w.Header().Get("ETag"), ShouldEqual, "1650000000-1")
+				So(w.Body.String(), ShouldEqual, `{"id":"123","state":"created"}`)
 			})
 		})
 	})
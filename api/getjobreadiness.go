@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+func (api *ImportAPI) getJobReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+	logData := log.Data{jobIDKey: jobID}
+
+	b, err := api.getJobReadiness(ctx, jobID, logData)
+	if err != nil {
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	writeResponse(ctx, w, http.StatusOK, b, "getJobReadiness", logData)
+	log.Info(ctx, "getJobReadiness endpoint: request successful", logData)
+}
+
+func (api *ImportAPI) getJobReadiness(ctx context.Context, jobID string, logData log.Data) (b []byte, err error) {
+	readiness, err := api.jobService.GetJobReadiness(ctx, jobID)
+	if err != nil {
+		log.Error(ctx, "getJobReadiness endpoint: failed to check job readiness", err, logData)
+		return
+	}
+
+	logData["readiness"] = readiness
+
+	b, err = json.Marshal(readiness)
+	if err != nil {
+		log.Error(ctx, "getJobReadiness endpoint: failed to marshal readiness resource into bytes", err, logData)
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetJobReadiness(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get the readiness of a job", t, func() {
+		Convey("When no auth token is provided", func() {
+			Convey("Then return status unauthorised (401)", func() {
+				api := SetupAPIWith(nil, nil)
+
+				r, err := testapi.CreateRequestWithOutAuth("GET", "http://localhost:21800/jobs/123/readiness", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+		})
+
+		Convey("When the job does not exist", func() {
+			Convey("Then return status not found (404)", func() {
+				mockJobService := &testapi.JobServiceMock{
+					GetJobReadinessFunc: func(ctx context.Context, jobID string) (*models.Readiness, error) {
+						return nil, errs.ErrJobNotFound
+					},
+				}
+				api := SetupAPIWith(nil, mockJobService)
+
+				r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs/123/readiness", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotFound.Error())
+			})
+		})
+
+		Convey("When the readiness of the job is checked", func() {
+			Convey("Then return status ok (200) with the missing and unexpected files", func() {
+				mockJobService := &testapi.JobServiceMock{
+					GetJobReadinessFunc: func(ctx context.Context, jobID string) (*models.Readiness, error) {
+						return &models.Readiness{MissingFiles: []string{"CPIH v4"}, UnexpectedFiles: []string{"wrong"}}, nil
+					},
+				}
+				api := SetupAPIWith(nil, mockJobService)
+
+				r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs/123/readiness", nil)
+				So(err, ShouldBeNil)
+
+				w := httptest.NewRecorder()
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, `{"ready":false,"missing_files":["CPIH v4"],"unexpected_files":["wrong"]}`)
+				So(mockJobService.GetJobReadinessCalls()[0].JobID, ShouldEqual, "123")
+			})
+		})
+	})
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
 	"github.com/ONSdigital/dp-import-api/utils"
 
 	"github.com/ONSdigital/log.go/v2/log"
@@ -16,21 +18,56 @@ func (api *ImportAPI) getJobsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logData := log.Data{}
 
-	filtersQuery := r.URL.Query().Get("state")
-	var filterList []string
-	if filtersQuery != "" {
-		filterList = strings.Split(filtersQuery, ",")
-		logData["filterQuery"] = filtersQuery
+	filter, err := getJobFilter(r.URL.Query(), logData)
+	if err != nil {
+		log.Error(ctx, "invalid job filter", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	fields, err := queryFields(r.URL.Query(), logData)
+	if err != nil {
+		log.Error(ctx, "invalid query parameter: fields", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	var sort []models.JobSort
+	if sortParameter := r.URL.Query().Get("sort"); sortParameter != "" {
+		logData["sort"] = sortParameter
+		if sort, err = models.ParseJobSort(sortParameter); err != nil {
+			log.Error(ctx, "invalid query parameter: sort", err, logData)
+			handleErr(ctx, w, err, logData)
+			return
+		}
 	}
 
 	offsetParameter := r.URL.Query().Get("offset")
 	limitParameter := r.URL.Query().Get("limit")
 
+	// a cursor, even an empty one requesting the first page, selects pages of jobs listed in last updated order
+	var cursor *models.JobCursor
+	cursorMode := r.URL.Query().Has("cursor")
+	if cursorMode {
+		if sort != nil || offsetParameter != "" {
+			err = errs.ErrorInvalidQueryParameter("cursor cannot be combined with offset or sort")
+			log.Error(ctx, "invalid query parameter: cursor", err, logData)
+			handleErr(ctx, w, err, logData)
+			return
+		}
+		if cursorParameter := r.URL.Query().Get("cursor"); cursorParameter != "" {
+			logData["cursor"] = cursorParameter
+			if cursor, err = models.ParseJobCursor(cursorParameter); err != nil {
+				log.Error(ctx, "invalid query parameter: cursor", err, logData)
+				handleErr(ctx, w, err, logData)
+				return
+			}
+		}
+	}
+
 	limit := api.defaultLimit
 	offset := api.defaultOffset
 
-	var err error
-
 	if offsetParameter != "" {
 		logData["offset"] = offsetParameter
 		offset, err = utils.ValidatePositiveInt(offsetParameter)
@@ -59,8 +96,25 @@ func (api *ImportAPI) getJobsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	b, err := api.getJobs(ctx, filterList, offset, limit, logData)
+	var jobResults *models.JobResults
+	if cursorMode {
+		jobResults, err = api.dataStore.GetJobsAfter(ctx, filter, cursor, fields, limit)
+	} else {
+		jobResults, err = api.dataStore.GetJobs(ctx, filter, sort, fields, offset, limit)
+	}
+	if err != nil {
+		log.Error(ctx, "getJobs endpoint: failed to retrieve a list of jobs", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	if jobResults.NextCursor != "" {
+		jobResults.Links = &models.JobResultsLinks{Next: &models.Link{HRef: api.nextPageURL(r.URL.Query(), jobResults.NextCursor)}}
+	}
+
+	b, err := json.Marshal(jobResults)
 	if err != nil {
+		log.Error(ctx, "getJobs endpoint: failed to marshal jobs resource into bytes", err, logData)
 		handleErr(ctx, w, err, logData)
 		return
 	}
@@ -69,17 +123,62 @@ func (api *ImportAPI) getJobsHandler(w http.ResponseWriter, r *http.Request) {
 	log.Info(ctx, "getJobs endpoint: request successful", logData)
 }
 
-func (api *ImportAPI) getJobs(ctx context.Context, filterList []string, offset int, limit int, logData log.Data) (b []byte, err error) {
-	jobResults, err := api.dataStore.GetJobs(ctx, filterList, offset, limit)
-	if err != nil {
-		log.Error(ctx, "getJobs endpoint: failed to retrieve a list of jobs", err, logData)
-		return
+// nextPageURL returns the URL of the page of jobs following the requested one, which starts after the provided cursor
+// and keeps the other query parameters of the request
+func (api *ImportAPI) nextPageURL(query url.Values, nextCursor string) string {
+	query.Set("cursor", nextCursor)
+	return api.urlBuilder.GetJobsURL(query.Encode())
+}
+
+// getJobFilter creates the job filter from the query parameters of a request. Parameters accepting several values
+// take them as a comma separated list, and dates must be RFC3339 timestamps.
+func getJobFilter(query url.Values, logData log.Data) (*models.JobFilter, error) {
+	filter := &models.JobFilter{
+		States:         queryList(query, "state", logData),
+		RecipeIDs:      queryList(query, "recipe", logData),
+		DatasetIDs:     queryList(query, "dataset_id", logData),
+		InstanceIDs:    queryList(query, "instance_id", logData),
+		FileAliasNames: queryList(query, "file_alias_name", logData),
+		FileURLs:       queryList(query, "file_url", logData),
 	}
 
-	b, err = json.Marshal(jobResults)
-	if err != nil {
-		log.Error(ctx, "getJobs endpoint: failed to marshal jobs resource into bytes", err, logData)
+	var err error
+	if filter.LastUpdatedFrom, err = queryDate(query, "last_updated_from", logData); err != nil {
+		return nil, err
+	}
+	if filter.LastUpdatedTo, err = queryDate(query, "last_updated_to", logData); err != nil {
+		return nil, err
+	}
+	if filter.CreatedFrom, err = queryDate(query, "created_from", logData); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = queryDate(query, "created_to", logData); err != nil {
+		return nil, err
+	}
+
+	return filter, filter.Validate()
+}
+
+// queryList returns the comma separated values of the provided query parameter, or nil if it is not set
+func queryList(query url.Values, key string, logData log.Data) []string {
+	value := query.Get(key)
+	if value == "" {
+		return nil
 	}
+	logData[key] = value
+	return strings.Split(value, ",")
+}
 
-	return b, nil
+// queryDate returns the RFC3339 date of the provided query parameter, or nil if it is not set
+func queryDate(query url.Values, key string, logData log.Data) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	logData[key] = value
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errs.ErrorInvalidQueryParameter(key + " is not an RFC3339 date")
+	}
+	return &t, nil
 }
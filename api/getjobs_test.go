@@ -1,12 +1,18 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-import-api/api/testapi"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/gorilla/mux"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -43,6 +49,32 @@ func TestFailureToGetJobs(t *testing.T) {
 				So(w.Body.String(), ShouldContainSubstring, errs.ErrInternalServer.Error())
 			})
 		})
+
+		Convey("When a filter is malformed", func() {
+			Convey("Then return status bad request (400)", func() {
+				for query, expectedErr := range map[string]string{
+					"state=started":          errs.ErrInvalidState.Error(),
+					"last_updated_from=2022": "last_updated_from is not an RFC3339 date",
+					"created_to=yesterday":   "created_to is not an RFC3339 date",
+					"sort=files":             "cannot sort by 'files'",
+					"sort=state:up":          "invalid sort direction 'up'",
+					"cursor=abc":             "invalid cursor",
+					"cursor=&offset=10":      "cursor cannot be combined with offset or sort",
+					"fields=state,unknown":   "unknown job field 'unknown'",
+					"created_from=2022-05-02T00:00:00Z&created_to=2022-05-01T00:00:00Z": "created_to is before created_from",
+				} {
+					api := SetupAPIWith(nil, nil)
+
+					w := httptest.NewRecorder()
+					r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?"+query, nil)
+					So(err, ShouldBeNil)
+
+					api.router.ServeHTTP(w, r)
+					So(w.Code, ShouldEqual, http.StatusBadRequest)
+					So(w.Body.String(), ShouldContainSubstring, expectedErr)
+				}
+			})
+		})
 	})
 }
 
@@ -78,4 +110,186 @@ func TestGetJobs(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given a request to get a list of jobs when no jobs match", t, func() {
+		Convey("When retrieval of resources from datastore is successful", func() {
+			Convey("Then return status ok (200) with an empty list of jobs", func() {
+				api := SetupAPIWith(&testapi.DstoreNotFound, nil)
+				w := httptest.NewRecorder()
+				r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?state=failed", nil)
+				So(err, ShouldBeNil)
+
+				api.router.ServeHTTP(w, r)
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldContainSubstring, `"total_count":0,"items":[]`)
+			})
+		})
+	})
+}
+
+func TestGetJobsWithFilters(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get a list of jobs with every filter", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobsFunc: func(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
+				return &models.JobResults{Items: []*models.Job{{ID: "34534543543"}}}, nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?state=created,submitted"+
+			"&recipe=b944be78&dataset_id=cpih01,mid-year-pop-est&instance_id=54321&file_alias_name=v4"+
+			"&file_url=s3://bucket/v4.csv&last_updated_from=2022-05-01T00:00:00Z&last_updated_to=2022-05-31T00:00:00Z"+
+			"&created_from=2022-04-01T00:00:00Z&created_to=2022-04-30T12:00:00%2B01:00", nil)
+		So(err, ShouldBeNil)
+
+		Convey("When the jobs are retrieved", func() {
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the filter is provided to the datastore and status ok (200) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsCalls(), ShouldHaveLength, 1)
+
+				filter := mockDataStore.GetJobsCalls()[0].Filter
+				So(filter.States, ShouldResemble, []string{"created", "submitted"})
+				So(filter.RecipeIDs, ShouldResemble, []string{"b944be78"})
+				So(filter.DatasetIDs, ShouldResemble, []string{"cpih01", "mid-year-pop-est"})
+				So(filter.InstanceIDs, ShouldResemble, []string{"54321"})
+				So(filter.FileAliasNames, ShouldResemble, []string{"v4"})
+				So(filter.FileURLs, ShouldResemble, []string{"s3://bucket/v4.csv"})
+				So(filter.LastUpdatedFrom.Equal(time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+				So(filter.LastUpdatedTo.Equal(time.Date(2022, 5, 31, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+				So(filter.CreatedFrom.Equal(time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+				So(filter.CreatedTo.Equal(time.Date(2022, 4, 30, 11, 0, 0, 0, time.UTC)), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestGetJobsWithSort(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get a list of jobs sorted by several fields", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobsFunc: func(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
+				return &models.JobResults{Items: []*models.Job{{ID: "34534543543"}}}, nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?sort=last_updated:desc,state:asc", nil)
+		So(err, ShouldBeNil)
+
+		Convey("When the jobs are retrieved", func() {
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the sort order is provided to the datastore and status ok (200) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobsCalls()[0].Sort, ShouldResemble, []models.JobSort{
+					{Field: "last_updated", Descending: true},
+					{Field: "state"},
+				})
+			})
+		})
+	})
+}
+
+func TestGetJobsWithCursor(t *testing.T) {
+	t.Parallel()
+
+	nextCursor := &models.JobCursor{LastUpdated: time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC), ID: "34534543543"}
+
+	Convey("Given a datastore with more jobs than fit in a page", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobsAfterFunc: func(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error) {
+				return &models.JobResults{
+					Items:      []*models.Job{{ID: "34534543543"}},
+					Count:      1,
+					TotalCount: 2,
+					Limit:      limit,
+					NextCursor: nextCursor.Encode(),
+				}, nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		Convey("When the first page is requested with an empty cursor", func() {
+			r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?state=created&cursor=&limit=1", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the jobs are retrieved from the start, with a link to the next page", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsAfterCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobsAfterCalls()[0].Cursor, ShouldBeNil)
+				So(mockDataStore.GetJobsAfterCalls()[0].Filter.States, ShouldResemble, []string{"created"})
+				So(mockDataStore.GetJobsAfterCalls()[0].Limit, ShouldEqual, 1)
+
+				var results models.JobResults
+				So(json.Unmarshal(w.Body.Bytes(), &results), ShouldBeNil)
+				So(results.NextCursor, ShouldEqual, nextCursor.Encode())
+				So(results.Links.Next.HRef, ShouldEqual, "http://localhost:21800/jobs?cursor="+nextCursor.Encode()+"&limit=1&state=created")
+			})
+		})
+
+		Convey("When the next page is requested with the returned cursor", func() {
+			r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?cursor="+nextCursor.Encode(), nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the jobs are retrieved after the cursor", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsAfterCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobsAfterCalls()[0].Cursor, ShouldResemble, nextCursor)
+			})
+		})
+	})
+}
+
+func TestGetJobsWithFields(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to get only some fields of a list of jobs", t, func() {
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobsFunc: func(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
+				return &models.JobResults{Items: []*models.Job{{ID: "34534543543", State: models.CreatedState}}}, nil
+			},
+			GetJobsAfterFunc: func(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error) {
+				return &models.JobResults{Items: []*models.Job{{ID: "34534543543", State: models.CreatedState}}}, nil
+			},
+		}
+		api := Setup(mux.NewRouter(), mockDataStore, &testapi.JobServiceMock{}, cfg)
+
+		Convey("When a page of jobs is retrieved by offset", func() {
+			r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?fields=state,last_updated", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the fields are provided to the datastore", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobsCalls()[0].Fields, ShouldResemble, []string{"state", "last_updated"})
+			})
+		})
+
+		Convey("When a page of jobs is retrieved by cursor", func() {
+			r, err := testapi.CreateRequestWithAuth("GET", "http://localhost:21800/jobs?cursor=&fields=state", nil)
+			So(err, ShouldBeNil)
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the fields are provided to the datastore", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockDataStore.GetJobsAfterCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetJobsAfterCalls()[0].Fields, ShouldResemble, []string{"state"})
+			})
+		})
+	})
 }
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
-	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
 	dphttp "github.com/ONSdigital/dp-net/http"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
@@ -20,45 +20,29 @@ func (api *ImportAPI) increaseProcessedInstanceHandler(w http.ResponseWriter, r
 	instanceID := vars["instance_id"]
 	logData := log.Data{jobIDKey: jobID, instanceIDKey: instanceID}
 
-	// Acquire imports lock so that the read and increase are atomic
-	lockID, err := api.dataStore.AcquireInstanceLock(ctx, jobID)
+	// The processed codelist ID or dimension name is optional, to support callers that only increase the count
+	processedDimension, err := models.CreateProcessedDimension(r.Body)
 	if err != nil {
 		handleErr(ctx, w, err, logData)
 		return
 	}
-	defer api.dataStore.UnlockInstance(ctx, lockID)
 
-	// Get import job from DB
-	job, err := api.dataStore.GetJob(ctx, jobID)
-	if err != nil {
-		handleErr(ctx, w, err, logData)
-		return
-	}
-
-	// Increase the count for the provided instance
-	found := false
-	for i, instance := range job.Processed {
-		if instance.ID == instanceID {
-			job.Processed[i].ProcessedCount++
-			found = true
-			break
-		}
+	dimension := ""
+	if processedDimension != nil {
+		dimension = processedDimension.Dimension
+		logData["dimension"] = dimension
 	}
 
-	if !found {
-		handleErr(ctx, w, errs.ErrInvalidInstanceID, logData)
-		return
-	}
-
-	// Update the processedInstance
-	if err := api.dataStore.UpdateProcessedInstance(ctx, jobID, job.Processed); err != nil {
+	// Increase the count for the provided instance, completing the job if all instances have been processed
+	processed, err := api.jobService.IncreaseProcessedInstance(ctx, jobID, instanceID, dimension, getIfMatch(r))
+	if err != nil {
 		handleErr(ctx, w, err, logData)
 		return
 	}
 	log.Info(ctx, "job update completed successfully", logData)
 
 	// marshal full Processed array as a response
-	b, err := json.Marshal(job.Processed)
+	b, err := json.Marshal(processed)
 	if err != nil {
 		handleErr(ctx, w, err, logData)
 		return
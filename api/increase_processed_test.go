@@ -2,12 +2,15 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/models"
 	testmongo "github.com/ONSdigital/dp-import-api/mongo/testmongo"
 	. "github.com/smartystreets/goconvey/convey"
@@ -19,12 +22,22 @@ func TestIncreaseProcessedInstanceHandler(t *testing.T) {
 
 	Convey("Given a request to increase the processed count for an instance", t, func() {
 		w := httptest.NewRecorder()
-		r, err := testapi.CreateRequestWithAuth(http.MethodPut, "http://localhost:21800/jobs/34534543543/processed/54321", nil)
+		r, err := testapi.CreateRequestWithAuth(http.MethodPut, "http://localhost:21800/jobs/34534543543/processed/54321", http.NoBody)
 		So(err, ShouldBeNil)
 
 		Convey("When the update is successful", func() {
-			ds := &testmongo.DataStorer{}
-			api := SetupAPIWith(ds, nil)
+			mockJobService := &testapi.JobServiceMock{
+				IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+					return []models.ProcessedInstances{
+						{
+							ID:             instanceID,
+							RequiredCount:  5,
+							ProcessedCount: 1,
+						},
+					}, nil
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
 			api.router.ServeHTTP(w, r)
 
 			Convey("Then the returned status code 200 OK, with the expected body", func() {
@@ -38,14 +51,53 @@ func TestIncreaseProcessedInstanceHandler(t *testing.T) {
 				})
 			})
 
-			Convey("Then the datastore has been locked, but is no longer locked", func() {
-				So(ds.HasBeenLocked, ShouldBeTrue)
-				So(ds.IsLocked, ShouldBeFalse)
+			Convey("Then the job service is called with the job and instance IDs, and no dimension", func() {
+				So(mockJobService.IncreaseProcessedInstanceCalls(), ShouldHaveLength, 1)
+				So(mockJobService.IncreaseProcessedInstanceCalls()[0].JobID, ShouldEqual, "34534543543")
+				So(mockJobService.IncreaseProcessedInstanceCalls()[0].InstanceID, ShouldEqual, "54321")
+				So(mockJobService.IncreaseProcessedInstanceCalls()[0].Dimension, ShouldBeEmpty)
 			})
 		})
 
-		Convey("When the datastore returns an InternalError", func() {
-			api := SetupAPIWith(&testapi.DstoreInternalError, nil)
+		Convey("When the request body contains the processed dimension", func() {
+			r, err := testapi.CreateRequestWithAuth(http.MethodPut, "http://localhost:21800/jobs/34534543543/processed/54321", strings.NewReader(`{"dimension":"aggregate"}`))
+			So(err, ShouldBeNil)
+			mockJobService := &testapi.JobServiceMock{
+				IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+					return []models.ProcessedInstances{}, nil
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the job service is called with the processed dimension", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.IncreaseProcessedInstanceCalls(), ShouldHaveLength, 1)
+				So(mockJobService.IncreaseProcessedInstanceCalls()[0].Dimension, ShouldEqual, "aggregate")
+			})
+		})
+
+		Convey("When the request body does not contain a dimension", func() {
+			r, err := testapi.CreateRequestWithAuth(http.MethodPut, "http://localhost:21800/jobs/34534543543/processed/54321", strings.NewReader(`{}`))
+			So(err, ShouldBeNil)
+			mockJobService := &testapi.JobServiceMock{}
+			api := SetupAPIWith(nil, mockJobService)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the returned status code 400 Bad request, with the expected body", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldEqual, errs.ErrInvalidProcessedDimension.Error()+"\n")
+				So(mockJobService.IncreaseProcessedInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the job service returns an InternalError", func() {
+			mockJobService := &testapi.JobServiceMock{
+				IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+					return nil, testmongo.InternalError
+				},
+			}
+			api := SetupAPIWith(&testapi.DstoreInternalError, mockJobService)
 			api.router.ServeHTTP(w, r)
 
 			Convey("Then the returned status code 500 Internal Server Error, with the expected body", func() {
@@ -56,7 +108,12 @@ func TestIncreaseProcessedInstanceHandler(t *testing.T) {
 
 		Convey("When the instance does not exist for the import job", func() {
 			r.URL.Path = "/jobs/34534543543/processed/inexistent"
-			api := SetupAPIWith(nil, nil)
+			mockJobService := &testapi.JobServiceMock{
+				IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+					return nil, errs.ErrInvalidInstanceID
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
 			api.router.ServeHTTP(w, r)
 
 			Convey("Then the returned status code 400 Bad request, with the expected body", func() {
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"mime"
+	"net/http"
+
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+const jsonPatchContentType = "application/json-patch+json"
+
+func (api *ImportAPI) patchJobHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer dphttp.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+	logData := log.Data{jobIDKey: jobID}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != jsonPatchContentType {
+		handleCustomErr(ctx, w, errs.ErrPatchContentType, logData, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err := api.patchJob(ctx, r, jobID, logData); err != nil {
+		handleErr(ctx, w, err, logData)
+		return
+	}
+	log.Info(ctx, "job patch successful", logData)
+}
+
+func (api *ImportAPI) patchJob(ctx context.Context, r *http.Request, jobID string, logData log.Data) (err error) {
+
+	patches, err := models.CreateJobPatches(r.Body)
+	if err != nil {
+		log.Error(ctx, "patchJob endpoint: failed to read patch operations", err, logData)
+		return
+	}
+	logData["patches"] = patches
+
+	if err = api.jobService.PatchJob(ctx, jobID, patches, getIfMatch(r)); err != nil {
+		log.Error(ctx, "patchJob endpoint: failed to patch job resource", err, logData)
+	}
+
+	return
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dprequest "github.com/ONSdigital/dp-net/request"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPatchJob(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to patch a job", t, func() {
+		mockJobService := &testapi.JobServiceMock{
+			PatchJobFunc: func(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+				return nil
+			},
+		}
+		api := SetupAPIWith(nil, mockJobService)
+
+		newRequest := func(body, contentType string) *http.Request {
+			r, err := testapi.CreateRequestWithAuth("PATCH", "http://localhost:21800/jobs/12345", strings.NewReader(body))
+			So(err, ShouldBeNil)
+			r.Header.Set("Content-Type", contentType)
+			return r
+		}
+
+		Convey("When the request contains valid patch operations", func() {
+			r := newRequest(`[{"op":"replace","path":"/state","value":"submitted"},{"op":"remove","path":"/files/v4"}]`, "application/json-patch+json")
+			r.Header.Set("If-Match", "1650000000-1")
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the patches are applied by the job service and status ok (200) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.PatchJobCalls(), ShouldHaveLength, 1)
+				So(mockJobService.PatchJobCalls()[0].JobID, ShouldEqual, "12345")
+				So(mockJobService.PatchJobCalls()[0].Patches, ShouldHaveLength, 2)
+				So(mockJobService.PatchJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+		})
+
+		Convey("When the request is not a json-patch message", func() {
+			r := newRequest(`{"state":"submitted"}`, "application/json")
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then status unsupported media type (415) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusUnsupportedMediaType)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrPatchContentType.Error())
+				So(mockJobService.PatchJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the request contains an unsupported operation", func() {
+			r := newRequest(`[{"op":"copy","path":"/files/-","from":"/files/v4"}]`, "application/json-patch+json")
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then status bad request (400) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidPatch.Error())
+				So(mockJobService.PatchJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the patch cannot be applied to the job", func() {
+			mockJobService.PatchJobFunc = func(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+				return errs.ErrorInvalidPatch(`no uploaded file with alias name "v4"`)
+			}
+			r := newRequest(`[{"op":"remove","path":"/files/v4"}]`, "application/json-patch+json")
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then status bad request (400) is returned with the reason", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, `no uploaded file with alias name "v4"`)
+			})
+		})
+
+		Convey("When the job has been modified", func() {
+			mockJobService.PatchJobFunc = func(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+				return errs.ErrJobETagMismatch
+			}
+			r := newRequest(`[{"op":"replace","path":"/state","value":"submitted"}]`, "application/json-patch+json")
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then status conflict (409) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+			})
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/dp-import-api/models"
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+func (api *ImportAPI) retryJobHandler(w http.ResponseWriter, r *http.Request) {
+
+	defer dphttp.DrainBody(r)
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+	logData := log.Data{jobIDKey: jobID}
+
+	options, err := models.CreateRetryOptions(r.Body)
+	if err != nil {
+		log.Error(ctx, "retryJob endpoint: failed to parse the retry options", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+
+	logData["options"] = options
+	if err := api.jobService.RetryJob(ctx, jobID, options); err != nil {
+		log.Error(ctx, "retryJob endpoint: failed to retry job", err, logData)
+		handleErr(ctx, w, err, logData)
+		return
+	}
+	log.Info(ctx, "job retried successfully", logData)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-import-api/api/testapi"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryJob(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to retry a job", t, func() {
+		w := httptest.NewRecorder()
+		mockJobService := &testapi.JobServiceMock{
+			RetryJobFunc: func(ctx context.Context, jobID string, options *models.RetryOptions) error {
+				return nil
+			},
+		}
+		api := SetupAPIWith(nil, mockJobService)
+
+		Convey("When request has no auth header", func() {
+			r, err := testapi.CreateRequestWithOutAuth("POST", "http://localhost:21800/jobs/12345/retry", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status unauthorised (401)", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(mockJobService.RetryJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the request has no body", func() {
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/retry", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status ok (200) and the job is retried with the default options", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.RetryJobCalls(), ShouldHaveLength, 1)
+				So(mockJobService.RetryJobCalls()[0].JobID, ShouldEqual, "12345")
+				So(mockJobService.RetryJobCalls()[0].Options, ShouldResemble, &models.RetryOptions{})
+			})
+		})
+
+		Convey("When the request asks for the instances to be recreated", func() {
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/retry", strings.NewReader(`{"recreate_instances":true}`))
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status ok (200) and the job is retried recreating its instances", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.RetryJobCalls(), ShouldHaveLength, 1)
+				So(mockJobService.RetryJobCalls()[0].Options, ShouldResemble, &models.RetryOptions{RecreateInstances: true})
+			})
+		})
+
+		Convey("When the request body is not valid json", func() {
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/retry", strings.NewReader(`{`))
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status bad request (400)", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(mockJobService.RetryJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the job has not failed", func() {
+			mockJobService.RetryJobFunc = func(ctx context.Context, jobID string, options *models.RetryOptions) error {
+				return errs.ErrJobNotFailed
+			}
+
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/retry", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status conflict (409)", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotFailed.Error())
+			})
+		})
+
+		Convey("When the job is not ready to be submitted again", func() {
+			mockJobService.RetryJobFunc = func(ctx context.Context, jobID string, options *models.RetryOptions) error {
+				return errs.ErrorJobNotRetriable(errs.ErrorJobNotReady([]string{"CPIH v4"}, []string{}))
+			}
+
+			r, err := testapi.CreateRequestWithAuth("POST", "http://localhost:21800/jobs/12345/retry", http.NoBody)
+			So(err, ShouldBeNil)
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then return status bad request (400) listing the missing files", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobNotRetriable.Error())
+				So(w.Body.String(), ShouldContainSubstring, "missing files: [CPIH v4]")
+			})
+		})
+	})
+}
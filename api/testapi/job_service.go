@@ -6,12 +6,19 @@ package testapi
 import (
 	"context"
 	"github.com/ONSdigital/dp-import-api/models"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	"sync"
 )
 
 var (
-	lockJobServiceMockCreateJob sync.RWMutex
-	lockJobServiceMockUpdateJob sync.RWMutex
+	lockJobServiceMockCancelJob                 sync.RWMutex
+	lockJobServiceMockCreateJob                 sync.RWMutex
+	lockJobServiceMockFormats                   sync.RWMutex
+	lockJobServiceMockGetJobReadiness           sync.RWMutex
+	lockJobServiceMockIncreaseProcessedInstance sync.RWMutex
+	lockJobServiceMockPatchJob                  sync.RWMutex
+	lockJobServiceMockRetryJob                  sync.RWMutex
+	lockJobServiceMockUpdateJob                 sync.RWMutex
 )
 
 // JobServiceMock is a mock implementation of api.JobService.
@@ -20,10 +27,28 @@ var (
 //
 //         // make and configure a mocked api.JobService
 //         mockedJobService := &JobServiceMock{
+//             CancelJobFunc: func(ctx context.Context, jobID string) error {
+// 	               panic("mock out the CancelJob method")
+//             },
 //             CreateJobFunc: func(ctx context.Context, job *models.Job) (*models.Job, error) {
 // 	               panic("mock out the CreateJob method")
 //             },
-//             UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+//             FormatsFunc: func() []string {
+// 	               panic("mock out the Formats method")
+//             },
+//             GetJobReadinessFunc: func(ctx context.Context, jobID string) (*models.Readiness, error) {
+// 	               panic("mock out the GetJobReadiness method")
+//             },
+//             IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+// 	               panic("mock out the IncreaseProcessedInstance method")
+//             },
+//             PatchJobFunc: func(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+// 	               panic("mock out the PatchJob method")
+//             },
+//             RetryJobFunc: func(ctx context.Context, jobID string, options *models.RetryOptions) error {
+// 	               panic("mock out the RetryJob method")
+//             },
+//             UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 // 	               panic("mock out the UpdateJob method")
 //             },
 //         }
@@ -33,14 +58,39 @@ var (
 //
 //     }
 type JobServiceMock struct {
+	// CancelJobFunc mocks the CancelJob method.
+	CancelJobFunc func(ctx context.Context, jobID string) error
+
 	// CreateJobFunc mocks the CreateJob method.
 	CreateJobFunc func(ctx context.Context, job *models.Job) (*models.Job, error)
 
+	// FormatsFunc mocks the Formats method.
+	FormatsFunc func() []string
+
+	// GetJobReadinessFunc mocks the GetJobReadiness method.
+	GetJobReadinessFunc func(ctx context.Context, jobID string) (*models.Readiness, error)
+
+	// IncreaseProcessedInstanceFunc mocks the IncreaseProcessedInstance method.
+	IncreaseProcessedInstanceFunc func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error)
+
+	// PatchJobFunc mocks the PatchJob method.
+	PatchJobFunc func(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error
+
+	// RetryJobFunc mocks the RetryJob method.
+	RetryJobFunc func(ctx context.Context, jobID string, options *models.RetryOptions) error
+
 	// UpdateJobFunc mocks the UpdateJob method.
-	UpdateJobFunc func(ctx context.Context, jobID string, job *models.Job) error
+	UpdateJobFunc func(ctx context.Context, jobID string, job *models.Job, eTag string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// CancelJob holds details about calls to the CancelJob method.
+		CancelJob []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+		}
 		// CreateJob holds details about calls to the CreateJob method.
 		CreateJob []struct {
 			// Ctx is the ctx argument value.
@@ -48,6 +98,49 @@ type JobServiceMock struct {
 			// Job is the job argument value.
 			Job *models.Job
 		}
+		// Formats holds details about calls to the Formats method.
+		Formats []struct {
+		}
+		// GetJobReadiness holds details about calls to the GetJobReadiness method.
+		GetJobReadiness []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+		}
+		// IncreaseProcessedInstance holds details about calls to the IncreaseProcessedInstance method.
+		IncreaseProcessedInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// ETag is the eTag argument value.
+			ETag string
+		}
+		// PatchJob holds details about calls to the PatchJob method.
+		PatchJob []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// Patches is the patches argument value.
+			Patches []dprequest.Patch
+			// ETag is the eTag argument value.
+			ETag string
+		}
+		// RetryJob holds details about calls to the RetryJob method.
+		RetryJob []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// Options is the options argument value.
+			Options *models.RetryOptions
+		}
 		// UpdateJob holds details about calls to the UpdateJob method.
 		UpdateJob []struct {
 			// Ctx is the ctx argument value.
@@ -56,10 +149,47 @@ type JobServiceMock struct {
 			JobID string
 			// Job is the job argument value.
 			Job *models.Job
+			// ETag is the eTag argument value.
+			ETag string
 		}
 	}
 }
 
+// CancelJob calls CancelJobFunc.
+func (mock *JobServiceMock) CancelJob(ctx context.Context, jobID string) error {
+	if mock.CancelJobFunc == nil {
+		panic("JobServiceMock.CancelJobFunc: method is nil but JobService.CancelJob was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		JobID string
+	}{
+		Ctx:   ctx,
+		JobID: jobID,
+	}
+	lockJobServiceMockCancelJob.Lock()
+	mock.calls.CancelJob = append(mock.calls.CancelJob, callInfo)
+	lockJobServiceMockCancelJob.Unlock()
+	return mock.CancelJobFunc(ctx, jobID)
+}
+
+// CancelJobCalls gets all the calls that were made to CancelJob.
+// Check the length with:
+//     len(mockedJobService.CancelJobCalls())
+func (mock *JobServiceMock) CancelJobCalls() []struct {
+	Ctx   context.Context
+	JobID string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		JobID string
+	}
+	lockJobServiceMockCancelJob.RLock()
+	calls = mock.calls.CancelJob
+	lockJobServiceMockCancelJob.RUnlock()
+	return calls
+}
+
 // CreateJob calls CreateJobFunc.
 func (mock *JobServiceMock) CreateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
 	if mock.CreateJobFunc == nil {
@@ -95,8 +225,198 @@ func (mock *JobServiceMock) CreateJobCalls() []struct {
 	return calls
 }
 
+// Formats calls FormatsFunc.
+func (mock *JobServiceMock) Formats() []string {
+	if mock.FormatsFunc == nil {
+		panic("JobServiceMock.FormatsFunc: method is nil but JobService.Formats was just called")
+	}
+	callInfo := struct {
+	}{}
+	lockJobServiceMockFormats.Lock()
+	mock.calls.Formats = append(mock.calls.Formats, callInfo)
+	lockJobServiceMockFormats.Unlock()
+	return mock.FormatsFunc()
+}
+
+// FormatsCalls gets all the calls that were made to Formats.
+// Check the length with:
+//     len(mockedJobService.FormatsCalls())
+func (mock *JobServiceMock) FormatsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	lockJobServiceMockFormats.RLock()
+	calls = mock.calls.Formats
+	lockJobServiceMockFormats.RUnlock()
+	return calls
+}
+
+// GetJobReadiness calls GetJobReadinessFunc.
+func (mock *JobServiceMock) GetJobReadiness(ctx context.Context, jobID string) (*models.Readiness, error) {
+	if mock.GetJobReadinessFunc == nil {
+		panic("JobServiceMock.GetJobReadinessFunc: method is nil but JobService.GetJobReadiness was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		JobID string
+	}{
+		Ctx:   ctx,
+		JobID: jobID,
+	}
+	lockJobServiceMockGetJobReadiness.Lock()
+	mock.calls.GetJobReadiness = append(mock.calls.GetJobReadiness, callInfo)
+	lockJobServiceMockGetJobReadiness.Unlock()
+	return mock.GetJobReadinessFunc(ctx, jobID)
+}
+
+// GetJobReadinessCalls gets all the calls that were made to GetJobReadiness.
+// Check the length with:
+//     len(mockedJobService.GetJobReadinessCalls())
+func (mock *JobServiceMock) GetJobReadinessCalls() []struct {
+	Ctx   context.Context
+	JobID string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		JobID string
+	}
+	lockJobServiceMockGetJobReadiness.RLock()
+	calls = mock.calls.GetJobReadiness
+	lockJobServiceMockGetJobReadiness.RUnlock()
+	return calls
+}
+
+// IncreaseProcessedInstance calls IncreaseProcessedInstanceFunc.
+func (mock *JobServiceMock) IncreaseProcessedInstance(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+	if mock.IncreaseProcessedInstanceFunc == nil {
+		panic("JobServiceMock.IncreaseProcessedInstanceFunc: method is nil but JobService.IncreaseProcessedInstance was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		JobID      string
+		InstanceID string
+		Dimension  string
+		ETag       string
+	}{
+		Ctx:        ctx,
+		JobID:      jobID,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		ETag:       eTag,
+	}
+	lockJobServiceMockIncreaseProcessedInstance.Lock()
+	mock.calls.IncreaseProcessedInstance = append(mock.calls.IncreaseProcessedInstance, callInfo)
+	lockJobServiceMockIncreaseProcessedInstance.Unlock()
+	return mock.IncreaseProcessedInstanceFunc(ctx, jobID, instanceID, dimension, eTag)
+}
+
+// IncreaseProcessedInstanceCalls gets all the calls that were made to IncreaseProcessedInstance.
+// Check the length with:
+//     len(mockedJobService.IncreaseProcessedInstanceCalls())
+func (mock *JobServiceMock) IncreaseProcessedInstanceCalls() []struct {
+	Ctx        context.Context
+	JobID      string
+	InstanceID string
+	Dimension  string
+	ETag       string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		JobID      string
+		InstanceID string
+		Dimension  string
+		ETag       string
+	}
+	lockJobServiceMockIncreaseProcessedInstance.RLock()
+	calls = mock.calls.IncreaseProcessedInstance
+	lockJobServiceMockIncreaseProcessedInstance.RUnlock()
+	return calls
+}
+
+// PatchJob calls PatchJobFunc.
+func (mock *JobServiceMock) PatchJob(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+	if mock.PatchJobFunc == nil {
+		panic("JobServiceMock.PatchJobFunc: method is nil but JobService.PatchJob was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		JobID   string
+		Patches []dprequest.Patch
+		ETag    string
+	}{
+		Ctx:     ctx,
+		JobID:   jobID,
+		Patches: patches,
+		ETag:    eTag,
+	}
+	lockJobServiceMockPatchJob.Lock()
+	mock.calls.PatchJob = append(mock.calls.PatchJob, callInfo)
+	lockJobServiceMockPatchJob.Unlock()
+	return mock.PatchJobFunc(ctx, jobID, patches, eTag)
+}
+
+// PatchJobCalls gets all the calls that were made to PatchJob.
+// Check the length with:
+//     len(mockedJobService.PatchJobCalls())
+func (mock *JobServiceMock) PatchJobCalls() []struct {
+	Ctx     context.Context
+	JobID   string
+	Patches []dprequest.Patch
+	ETag    string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		JobID   string
+		Patches []dprequest.Patch
+		ETag    string
+	}
+	lockJobServiceMockPatchJob.RLock()
+	calls = mock.calls.PatchJob
+	lockJobServiceMockPatchJob.RUnlock()
+	return calls
+}
+
+// RetryJob calls RetryJobFunc.
+func (mock *JobServiceMock) RetryJob(ctx context.Context, jobID string, options *models.RetryOptions) error {
+	if mock.RetryJobFunc == nil {
+		panic("JobServiceMock.RetryJobFunc: method is nil but JobService.RetryJob was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		JobID   string
+		Options *models.RetryOptions
+	}{
+		Ctx:     ctx,
+		JobID:   jobID,
+		Options: options,
+	}
+	lockJobServiceMockRetryJob.Lock()
+	mock.calls.RetryJob = append(mock.calls.RetryJob, callInfo)
+	lockJobServiceMockRetryJob.Unlock()
+	return mock.RetryJobFunc(ctx, jobID, options)
+}
+
+// RetryJobCalls gets all the calls that were made to RetryJob.
+// Check the length with:
+//     len(mockedJobService.RetryJobCalls())
+func (mock *JobServiceMock) RetryJobCalls() []struct {
+	Ctx     context.Context
+	JobID   string
+	Options *models.RetryOptions
+} {
+	var calls []struct {
+		Ctx     context.Context
+		JobID   string
+		Options *models.RetryOptions
+	}
+	lockJobServiceMockRetryJob.RLock()
+	calls = mock.calls.RetryJob
+	lockJobServiceMockRetryJob.RUnlock()
+	return calls
+}
+
 // UpdateJob calls UpdateJobFunc.
-func (mock *JobServiceMock) UpdateJob(ctx context.Context, jobID string, job *models.Job) error {
+func (mock *JobServiceMock) UpdateJob(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 	if mock.UpdateJobFunc == nil {
 		panic("JobServiceMock.UpdateJobFunc: method is nil but JobService.UpdateJob was just called")
 	}
@@ -104,15 +424,17 @@ func (mock *JobServiceMock) UpdateJob(ctx context.Context, jobID string, job *mo
 		Ctx   context.Context
 		JobID string
 		Job   *models.Job
+		ETag  string
 	}{
 		Ctx:   ctx,
 		JobID: jobID,
 		Job:   job,
+		ETag:  eTag,
 	}
 	lockJobServiceMockUpdateJob.Lock()
 	mock.calls.UpdateJob = append(mock.calls.UpdateJob, callInfo)
 	lockJobServiceMockUpdateJob.Unlock()
-	return mock.UpdateJobFunc(ctx, jobID, job)
+	return mock.UpdateJobFunc(ctx, jobID, job, eTag)
 }
 
 // UpdateJobCalls gets all the calls that were made to UpdateJob.
@@ -122,11 +444,13 @@ func (mock *JobServiceMock) UpdateJobCalls() []struct {
 	Ctx   context.Context
 	JobID string
 	Job   *models.Job
+	ETag  string
 } {
 	var calls []struct {
 		Ctx   context.Context
 		JobID string
 		Job   *models.Job
+		ETag  string
 	}
 	lockJobServiceMockUpdateJob.RLock()
 	calls = mock.calls.UpdateJob
@@ -41,7 +41,7 @@ func (api *ImportAPI) updateJob(ctx context.Context, r *http.Request, jobID stri
 		return
 	}
 
-	if err = api.jobService.UpdateJob(ctx, jobID, job); err != nil {
+	if err = api.jobService.UpdateJob(ctx, jobID, job, getIfMatch(r)); err != nil {
 		log.Error(ctx, "updateJob endpoint: failed to store updated job resource", err, logData)
 	}
 
@@ -21,7 +21,7 @@ func TestFailureToUpdateJobState(t *testing.T) {
 		Convey("When request has no auth header", func() {
 			Convey("Then return status unauthorised (401)", func() {
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return nil
 					},
 				}
@@ -53,7 +53,7 @@ func TestFailureToUpdateJobState(t *testing.T) {
 				w := httptest.NewRecorder()
 
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return errs.ErrJobNotFound
 					},
 				}
@@ -80,7 +80,7 @@ func TestFailureToUpdateJobState(t *testing.T) {
 				w := httptest.NewRecorder()
 
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return errs.ErrJobNotFound
 					},
 				}
@@ -99,6 +99,28 @@ func TestFailureToUpdateJobState(t *testing.T) {
 			})
 		})
 
+		Convey("When the job service rejects fields of the request body that are managed by the import api", func() {
+			Convey("Then return status bad request (400) with the error listing the read-only fields", func() {
+				reader := strings.NewReader(`{"state":"submitted","id":"456","links":{"self":{"id":"456"}}}`)
+				r, err := testapi.CreateRequestWithAuth("PUT", "http://localhost:21800/jobs/12345", reader)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+
+				mockJobService := &testapi.JobServiceMock{
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
+						return errs.ErrorReadOnlyJobFields([]string{"id", "links"})
+					},
+				}
+
+				api := SetupAPIWith(nil, mockJobService)
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrReadOnlyJobFields.Error()+": id, links")
+				So(mockJobService.UpdateJobCalls(), ShouldHaveLength, 1)
+			})
+		})
+
 		Convey("When the job does not exist", func() {
 			Convey("Then return status not found (404)", func() {
 				reader := strings.NewReader("{\"state\":\"created\"}")
@@ -107,7 +129,7 @@ func TestFailureToUpdateJobState(t *testing.T) {
 				w := httptest.NewRecorder()
 
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return errs.ErrJobNotFound
 					},
 				}
@@ -126,10 +148,37 @@ func TestFailureToUpdateJobState(t *testing.T) {
 			})
 		})
 
+		Convey("When the job cannot be moved to the requested state", func() {
+			Convey("Then return status conflict (409)", func() {
+				reader := strings.NewReader("{\"state\":\"submitted\"}")
+				r, err := testapi.CreateRequestWithAuth("PUT", "http://localhost:21800/jobs/12345", reader)
+				So(err, ShouldBeNil)
+				w := httptest.NewRecorder()
+
+				mockJobService := &testapi.JobServiceMock{
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
+						return errs.ErrInvalidStateTransition
+					},
+				}
+
+				api := SetupAPIWith(nil, mockJobService)
+				api.router.ServeHTTP(w, r)
+
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrInvalidStateTransition.Error())
+
+				Convey("Then the request body has been drained", func() {
+					bytesRead, err := r.Body.Read(make([]byte, 1))
+					So(bytesRead, ShouldEqual, 0)
+					So(err, ShouldEqual, io.EOF)
+				})
+			})
+		})
+
 		Convey("When the import api is unable to connect to its datastore", func() {
 			Convey("Then return status internal server error (500)", func() {
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return errs.ErrInternalServer
 					},
 				}
@@ -162,7 +211,7 @@ func TestSuccessfullyUpdateJobState(t *testing.T) {
 		Convey("When successfully updating job state", func() {
 			Convey("Then return status ok (200)", func() {
 				mockJobService := &testapi.JobServiceMock{
-					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job) error {
+					UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
 						return nil
 					},
 				}
@@ -176,6 +225,7 @@ func TestSuccessfullyUpdateJobState(t *testing.T) {
 				api.router.ServeHTTP(w, r)
 
 				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.UpdateJobCalls()[0].ETag, ShouldEqual, models.AnyETag)
 
 				Convey("Then the request body has been drained", func() {
 					bytesRead, err := r.Body.Read(make([]byte, 1))
@@ -186,3 +236,48 @@ func TestSuccessfullyUpdateJobState(t *testing.T) {
 		})
 	})
 }
+
+func TestUpdateJobStateWithIfMatch(t *testing.T) {
+	t.Parallel()
+
+	Convey("Given a request to update job state with an If-Match header", t, func() {
+		reader := strings.NewReader(`{"state":"submitted"}`)
+		r, err := testapi.CreateRequestWithAuth("PUT", "http://localhost:21800/jobs/12345", reader)
+		So(err, ShouldBeNil)
+		r.Header.Set("If-Match", "1650000000-1")
+
+		Convey("When the eTag matches the job", func() {
+			mockJobService := &testapi.JobServiceMock{
+				UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
+					return nil
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then the eTag is provided to the job service and status ok (200) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(mockJobService.UpdateJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+		})
+
+		Convey("When the eTag does not match the job", func() {
+			mockJobService := &testapi.JobServiceMock{
+				UpdateJobFunc: func(ctx context.Context, jobID string, job *models.Job, eTag string) error {
+					return errs.ErrJobETagMismatch
+				},
+			}
+			api := SetupAPIWith(nil, mockJobService)
+
+			w := httptest.NewRecorder()
+			api.router.ServeHTTP(w, r)
+
+			Convey("Then status conflict (409) is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(w.Body.String(), ShouldContainSubstring, errs.ErrJobETagMismatch.Error())
+			})
+		})
+	})
+}
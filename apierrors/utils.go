@@ -2,7 +2,9 @@ package apierrors
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 )
 
 // A list of error messages that could be returned by Import API
@@ -14,14 +16,42 @@ var (
 	ErrInvalidPositiveInteger    = errors.New("value is not a positive integer")
 	ErrInternalServer            = errors.New("internal error")
 	ErrInvalidState              = errors.New("invalid state")
+	ErrInvalidStateTransition    = errors.New("the job cannot be moved from its current state to the requested state")
+	ErrJobCancelled              = errors.New("the job has been cancelled")
+	ErrJobNotFailed              = errors.New("only failed jobs can be retried")
+	ErrJobNotRetriable           = errors.New("the job cannot be submitted again")
 	ErrInvalidUploadedFileObject = errors.New("invalid json object received, alias_name and url are required")
 	ErrInvalidInstanceID         = errors.New("the instance id was not found in the provided job")
+	ErrInvalidProcessedDimension = errors.New("invalid json object received, dimension is required")
 	ErrJobNotFound               = errors.New("job not found")
+	ErrUploadedFileNotFound      = errors.New("uploaded file not found")
+	ErrJobNotReady               = errors.New("the job is not ready to be submitted")
+	ErrJobNotImportable          = errors.New("the job cannot be imported")
+	ErrJobFilesLocked            = errors.New("the files of a job can only be changed before it is submitted")
+	ErrInvalidPatch              = errors.New("the patch is not valid")
+	ErrPatchContentType          = errors.New("the content type of a patch must be application/json-patch+json")
+	ErrReadOnlyJobFields         = errors.New("the following job fields are managed by the import api and cannot be updated")
+	ErrDuplicateIdempotencyKey   = errors.New("a job has already been created with the provided idempotency key")
+	ErrJobETagMismatch           = errors.New("the job has been modified, its eTag does not match the If-Match header")
+	ErrUnsupportedFormat         = errors.New("the format of the recipe is not supported")
+	ErrUnmappableRecipe          = errors.New("the input files of the recipe cannot be mapped to its output instances")
 	ErrMissingProperties         = errors.New("missing properties to create import job")
 	ErrUnauthorised              = errors.New("unauthenticated request")
 
 	NotFoundMap = map[error]bool{
-		ErrJobNotFound: true,
+		ErrJobNotFound:          true,
+		ErrUploadedFileNotFound: true,
+	}
+
+	ConflictMap = map[error]bool{
+		ErrInvalidStateTransition:  true,
+		ErrJobCancelled:            true,
+		ErrJobNotFailed:            true,
+		ErrDuplicateIdempotencyKey: true,
+		ErrJobETagMismatch:         true,
+		ErrJobFilesLocked:          true,
+		ErrJobNotReady:             true,
+		ErrJobNotImportable:        true,
 	}
 
 	BadRequestMap = map[error]bool{
@@ -33,7 +63,13 @@ var (
 		ErrInvalidState:              true,
 		ErrInvalidUploadedFileObject: true,
 		ErrInvalidInstanceID:         true,
+		ErrInvalidProcessedDimension: true,
 		ErrMissingProperties:         true,
+		ErrUnsupportedFormat:         true,
+		ErrUnmappableRecipe:          true,
+		ErrInvalidPatch:              true,
+		ErrReadOnlyJobFields:         true,
+		ErrJobNotRetriable:           true,
 	}
 )
 
@@ -41,3 +77,41 @@ var (
 func ErrorMaximumLimitReached(m int) error {
 	return errors.New("the maximum limit has been reached, the limit cannot be more than " + strconv.Itoa(m))
 }
+
+// ErrorInvalidQueryParameter creates an invalid query parameter error with the provided reason
+func ErrorInvalidQueryParameter(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQueryParameter, reason)
+}
+
+// ErrorJobNotImportable creates an error giving the reason why the import events of a job cannot be produced
+func ErrorJobNotImportable(reason error) error {
+	return fmt.Errorf("%w: %s", ErrJobNotImportable, reason.Error())
+}
+
+// ErrorUnmappableRecipe creates an error giving the number of input files and output instances of a recipe
+// whose input files cannot be mapped to its output instances
+func ErrorUnmappableRecipe(inputFiles, outputInstances int) error {
+	return fmt.Errorf("%w: %d input files for %d output instances", ErrUnmappableRecipe, inputFiles, outputInstances)
+}
+
+// ErrorJobNotRetriable creates an error giving the reason why a failed job cannot be submitted again. The reason is
+// wrapped, so that it can still be identified.
+func ErrorJobNotRetriable(reason error) error {
+	return fmt.Errorf("%w: %w", ErrJobNotRetriable, reason)
+}
+
+// ErrorReadOnlyJobFields creates an error listing the provided job fields that cannot be updated
+func ErrorReadOnlyJobFields(fields []string) error {
+	return fmt.Errorf("%w: %s", ErrReadOnlyJobFields, strings.Join(fields, ", "))
+}
+
+// ErrorInvalidPatch creates an error describing why a patch is not valid
+func ErrorInvalidPatch(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPatch, reason)
+}
+
+// ErrorJobNotReady creates an error listing the files that are missing from a job, and the files that are not expected by its recipe
+func ErrorJobNotReady(missingFiles, unexpectedFiles []string) error {
+	return fmt.Errorf("%w, missing files: [%s], unexpected files: [%s]", ErrJobNotReady,
+		strings.Join(missingFiles, ", "), strings.Join(unexpectedFiles, ", "))
+}
@@ -45,6 +45,8 @@ type Configuration struct {
 	DefaultLimit               int           `envconfig:"DEFAULT_LIMIT"`
 	DefaultMaxLimit            int           `envconfig:"DEFAULT_MAXIMUM_LIMIT"`
 	DefaultOffset              int           `envconfig:"DEFAULT_OFFSET"`
+	OutboxRelayInterval        time.Duration `envconfig:"OUTBOX_RELAY_INTERVAL"`
+	IdempotencyKeyRetention    time.Duration `envconfig:"IDEMPOTENCY_KEY_RETENTION"`
 	KafkaConfig
 	MongoConfig
 }
@@ -52,8 +54,8 @@ type Configuration struct {
 var cfg *Configuration
 
 const (
-	ImportsCollection     = "ImportsCollection"
-	ImportsLockCollection = "ImportsLockCollection"
+	ImportsCollection = "ImportsCollection"
+	OutboxCollection  = "OutboxCollection"
 )
 
 // Get the application and returns the configuration structure
@@ -75,6 +77,8 @@ func Get() (*Configuration, error) {
 		DefaultLimit:               20,
 		DefaultMaxLimit:            1000,
 		DefaultOffset:              0,
+		OutboxRelayInterval:        30 * time.Second,
+		IdempotencyKeyRetention:    24 * time.Hour,
 		KafkaConfig: KafkaConfig{
 			Brokers:                               []string{"localhost:9092", "localhost:9093", "localhost:9094"},
 			DatabakerImportTopic:                  "data-bake-job-available",
@@ -89,7 +93,7 @@ func Get() (*Configuration, error) {
 			Username:                      "",
 			Password:                      "",
 			Database:                      "imports",
-			Collections:                   map[string]string{ImportsCollection: "imports", ImportsLockCollection: "imports_locks"},
+			Collections:                   map[string]string{ImportsCollection: "imports", OutboxCollection: "imports_outbox"},
 			ReplicaSet:                    "",
 			IsStrongReadConcernEnabled:    false,
 			IsWriteConcernMajorityEnabled: true,
@@ -127,6 +131,16 @@ func (config Configuration) validate() (issues []string) {
 		issues = append(issues, "got a KAFKA_SEC_CLIENT_KEY value, so require KAFKA_SEC_CLIENT_CERT to have a value")
 	}
 
+	if config.OutboxRelayInterval <= 0 {
+		issues = append(issues, "OUTBOX_RELAY_INTERVAL must be greater than zero")
+	}
+
+	for _, collection := range []string{ImportsCollection, OutboxCollection} {
+		if config.MongoConfig.Collections[collection] == "" {
+			issues = append(issues, fmt.Sprintf("MONGODB_COLLECTIONS has no value for %s", collection))
+		}
+	}
+
 	return
 }
 
@@ -23,6 +23,8 @@ var expectedConfig = &Configuration{
 	DefaultLimit:               20,
 	DefaultMaxLimit:            1000,
 	DefaultOffset:              0,
+	OutboxRelayInterval:        30 * time.Second,
+	IdempotencyKeyRetention:    24 * time.Hour,
 	KafkaConfig: KafkaConfig{
 		Brokers:                               []string{"localhost:9092", "localhost:9093", "localhost:9094"},
 		DatabakerImportTopic:                  "data-bake-job-available",
@@ -37,7 +39,7 @@ var expectedConfig = &Configuration{
 		Username:                      "",
 		Password:                      "",
 		Database:                      "imports",
-		Collections:                   map[string]string{ImportsCollection: "imports", ImportsLockCollection: "imports_locks"},
+		Collections:                   map[string]string{ImportsCollection: "imports", OutboxCollection: "imports_outbox"},
 		ReplicaSet:                    "",
 		IsStrongReadConcernEnabled:    false,
 		IsWriteConcernMajorityEnabled: true,
@@ -83,6 +85,26 @@ func TestGetReturnsDefaultValues(t *testing.T) {
 			})
 		})
 
+		Convey("When configuration is called with an outbox relay interval of zero", func() {
+			_ = os.Setenv("OUTBOX_RELAY_INTERVAL", "0s")
+			configuration, err := Get()
+
+			Convey("Then an error is returned", func() {
+				So(configuration, ShouldBeNil)
+				So(err.Error(), ShouldEqual, "validation of config failed: OUTBOX_RELAY_INTERVAL must be greater than zero")
+			})
+		})
+
+		Convey("When configuration is called with collections missing the outbox collection", func() {
+			_ = os.Setenv("MONGODB_COLLECTIONS", "ImportsCollection:imports")
+			configuration, err := Get()
+
+			Convey("Then an error is returned", func() {
+				So(configuration, ShouldBeNil)
+				So(err.Error(), ShouldEqual, "validation of config failed: MONGODB_COLLECTIONS has no value for OutboxCollection")
+			})
+		})
+
 		Convey("When configuration is called with a valid cert and key", func() {
 			secExpectedConfig := *expectedConfig
 			secExpectedConfig.KafkaConfig.SecClientKey = "open sesame"
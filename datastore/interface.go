@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"context"
+	"time"
 
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	"github.com/ONSdigital/dp-import-api/models"
@@ -13,12 +14,20 @@ import (
 type DataStorer interface {
 	AddJob(ctx context.Context, importJob *models.Job) (*models.Job, error)
 	GetJob(ctx context.Context, jobID string) (*models.Job, error)
-	GetJobs(ctx context.Context, filters []string, offset int, limit int) (*models.JobResults, error)
-	UpdateJob(ctx context.Context, jobID string, update *models.Job) error
-	UpdateProcessedInstance(ctx context.Context, id string, procInstances []models.ProcessedInstances) error
-	AddUploadedFile(ctx context.Context, jobID string, message *models.UploadedFile) error
+	GetJobFields(ctx context.Context, jobID string, fields []string) (*models.Job, error)
+	GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error)
+	GetJobs(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error)
+	GetJobsAfter(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error)
+	UpdateJob(ctx context.Context, jobID string, update *models.Job, eTag string) error
+	RetryJob(ctx context.Context, jobID string, update *models.Job) error
+	IncreaseProcessedInstance(ctx context.Context, jobID, instanceID, dimension, eTag string) ([]models.ProcessedInstances, error)
+	AddUploadedFile(ctx context.Context, jobID string, message *models.UploadedFile, eTag string) error
+	DeleteUploadedFile(ctx context.Context, jobID, aliasName, eTag string) error
+	AddOutboxMessage(ctx context.Context, message *models.OutboxMessage) error
+	GetPendingOutboxMessages(ctx context.Context, createdBefore, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error)
+	ClaimOutboxMessage(ctx context.Context, id string, claimedBefore time.Time) (bool, error)
+	UpdateOutboxMessageState(ctx context.Context, id, state string) error
+	IncreaseOutboxMessageAttempts(ctx context.Context, id string) error
 	Close(context.Context) error
 	Checker(context.Context, *healthcheck.CheckState) error
-	AcquireInstanceLock(ctx context.Context, jobID string) (lockID string, err error)
-	UnlockInstance(ctx context.Context, lockID string)
 }
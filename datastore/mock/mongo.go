@@ -9,6 +9,7 @@ import (
 	"github.com/ONSdigital/dp-import-api/datastore"
 	"github.com/ONSdigital/dp-import-api/models"
 	"sync"
+	"time"
 )
 
 // Ensure, that DataStorerMock does implement datastore.DataStorer.
@@ -21,35 +22,59 @@ var _ datastore.DataStorer = &DataStorerMock{}
 //
 // 		// make and configure a mocked datastore.DataStorer
 // 		mockedDataStorer := &DataStorerMock{
-// 			AcquireInstanceLockFunc: func(ctx context.Context, jobID string) (string, error) {
-// 				panic("mock out the AcquireInstanceLock method")
-// 			},
 // 			AddJobFunc: func(ctx context.Context, importJob *models.Job) (*models.Job, error) {
 // 				panic("mock out the AddJob method")
 // 			},
-// 			AddUploadedFileFunc: func(ctx context.Context, jobID string, message *models.UploadedFile) error {
+// 			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+// 				panic("mock out the AddOutboxMessage method")
+// 			},
+// 			AddUploadedFileFunc: func(ctx context.Context, jobID string, message *models.UploadedFile, eTag string) error {
 // 				panic("mock out the AddUploadedFile method")
 // 			},
 // 			CheckerFunc: func(contextMoqParam context.Context, checkState *healthcheck.CheckState) error {
 // 				panic("mock out the Checker method")
 // 			},
+// 			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+// 				panic("mock out the ClaimOutboxMessage method")
+// 			},
 // 			CloseFunc: func(contextMoqParam context.Context) error {
 // 				panic("mock out the Close method")
 // 			},
+// 			DeleteUploadedFileFunc: func(ctx context.Context, jobID string, aliasName string, eTag string) error {
+// 				panic("mock out the DeleteUploadedFile method")
+// 			},
 // 			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
 // 				panic("mock out the GetJob method")
 // 			},
-// 			GetJobsFunc: func(ctx context.Context, filters []string, offset int, limit int) (*models.JobResults, error) {
+// 			GetJobByIdempotencyKeyFunc: func(ctx context.Context, key string) (*models.Job, error) {
+// 				panic("mock out the GetJobByIdempotencyKey method")
+// 			},
+// 			GetJobFieldsFunc: func(ctx context.Context, jobID string, fields []string) (*models.Job, error) {
+// 				panic("mock out the GetJobFields method")
+// 			},
+// 			GetJobsFunc: func(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
 // 				panic("mock out the GetJobs method")
 // 			},
-// 			UnlockInstanceFunc: func(ctx context.Context, lockID string)  {
-// 				panic("mock out the UnlockInstance method")
+// 			GetJobsAfterFunc: func(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error) {
+// 				panic("mock out the GetJobsAfter method")
+// 			},
+// 			GetPendingOutboxMessagesFunc: func(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+// 				panic("mock out the GetPendingOutboxMessages method")
+// 			},
+// 			IncreaseOutboxMessageAttemptsFunc: func(ctx context.Context, id string) error {
+// 				panic("mock out the IncreaseOutboxMessageAttempts method")
+// 			},
+// 			IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+// 				panic("mock out the IncreaseProcessedInstance method")
 // 			},
-// 			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job) error {
+// 			RetryJobFunc: func(ctx context.Context, jobID string, update *models.Job) error {
+// 				panic("mock out the RetryJob method")
+// 			},
+// 			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
 // 				panic("mock out the UpdateJob method")
 // 			},
-// 			UpdateProcessedInstanceFunc: func(ctx context.Context, id string, procInstances []models.ProcessedInstances) error {
-// 				panic("mock out the UpdateProcessedInstance method")
+// 			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+// 				panic("mock out the UpdateOutboxMessageState method")
 // 			},
 // 		}
 //
@@ -58,45 +83,62 @@ var _ datastore.DataStorer = &DataStorerMock{}
 //
 // 	}
 type DataStorerMock struct {
-	// AcquireInstanceLockFunc mocks the AcquireInstanceLock method.
-	AcquireInstanceLockFunc func(ctx context.Context, jobID string) (string, error)
-
 	// AddJobFunc mocks the AddJob method.
 	AddJobFunc func(ctx context.Context, importJob *models.Job) (*models.Job, error)
 
+	// AddOutboxMessageFunc mocks the AddOutboxMessage method.
+	AddOutboxMessageFunc func(ctx context.Context, message *models.OutboxMessage) error
+
 	// AddUploadedFileFunc mocks the AddUploadedFile method.
-	AddUploadedFileFunc func(ctx context.Context, jobID string, message *models.UploadedFile) error
+	AddUploadedFileFunc func(ctx context.Context, jobID string, message *models.UploadedFile, eTag string) error
 
 	// CheckerFunc mocks the Checker method.
 	CheckerFunc func(contextMoqParam context.Context, checkState *healthcheck.CheckState) error
 
+	// ClaimOutboxMessageFunc mocks the ClaimOutboxMessage method.
+	ClaimOutboxMessageFunc func(ctx context.Context, id string, claimedBefore time.Time) (bool, error)
+
 	// CloseFunc mocks the Close method.
 	CloseFunc func(contextMoqParam context.Context) error
 
+	// DeleteUploadedFileFunc mocks the DeleteUploadedFile method.
+	DeleteUploadedFileFunc func(ctx context.Context, jobID string, aliasName string, eTag string) error
+
 	// GetJobFunc mocks the GetJob method.
 	GetJobFunc func(ctx context.Context, jobID string) (*models.Job, error)
 
+	// GetJobByIdempotencyKeyFunc mocks the GetJobByIdempotencyKey method.
+	GetJobByIdempotencyKeyFunc func(ctx context.Context, key string) (*models.Job, error)
+
+	// GetJobFieldsFunc mocks the GetJobFields method.
+	GetJobFieldsFunc func(ctx context.Context, jobID string, fields []string) (*models.Job, error)
+
 	// GetJobsFunc mocks the GetJobs method.
-	GetJobsFunc func(ctx context.Context, filters []string, offset int, limit int) (*models.JobResults, error)
+	GetJobsFunc func(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error)
+
+	// GetJobsAfterFunc mocks the GetJobsAfter method.
+	GetJobsAfterFunc func(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error)
 
-	// UnlockInstanceFunc mocks the UnlockInstance method.
-	UnlockInstanceFunc func(ctx context.Context, lockID string)
+	// GetPendingOutboxMessagesFunc mocks the GetPendingOutboxMessages method.
+	GetPendingOutboxMessagesFunc func(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error)
+
+	// IncreaseOutboxMessageAttemptsFunc mocks the IncreaseOutboxMessageAttempts method.
+	IncreaseOutboxMessageAttemptsFunc func(ctx context.Context, id string) error
+
+	// IncreaseProcessedInstanceFunc mocks the IncreaseProcessedInstance method.
+	IncreaseProcessedInstanceFunc func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error)
+
+	// RetryJobFunc mocks the RetryJob method.
+	RetryJobFunc func(ctx context.Context, jobID string, update *models.Job) error
 
 	// UpdateJobFunc mocks the UpdateJob method.
-	UpdateJobFunc func(ctx context.Context, jobID string, update *models.Job) error
+	UpdateJobFunc func(ctx context.Context, jobID string, update *models.Job, eTag string) error
 
-	// UpdateProcessedInstanceFunc mocks the UpdateProcessedInstance method.
-	UpdateProcessedInstanceFunc func(ctx context.Context, id string, procInstances []models.ProcessedInstances) error
+	// UpdateOutboxMessageStateFunc mocks the UpdateOutboxMessageState method.
+	UpdateOutboxMessageStateFunc func(ctx context.Context, id string, state string) error
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// AcquireInstanceLock holds details about calls to the AcquireInstanceLock method.
-		AcquireInstanceLock []struct {
-			// Ctx is the ctx argument value.
-			Ctx context.Context
-			// JobID is the jobID argument value.
-			JobID string
-		}
 		// AddJob holds details about calls to the AddJob method.
 		AddJob []struct {
 			// Ctx is the ctx argument value.
@@ -104,6 +146,13 @@ type DataStorerMock struct {
 			// ImportJob is the importJob argument value.
 			ImportJob *models.Job
 		}
+		// AddOutboxMessage holds details about calls to the AddOutboxMessage method.
+		AddOutboxMessage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Message is the message argument value.
+			Message *models.OutboxMessage
+		}
 		// AddUploadedFile holds details about calls to the AddUploadedFile method.
 		AddUploadedFile []struct {
 			// Ctx is the ctx argument value.
@@ -112,6 +161,8 @@ type DataStorerMock struct {
 			JobID string
 			// Message is the message argument value.
 			Message *models.UploadedFile
+			// ETag is the eTag argument value.
+			ETag string
 		}
 		// Checker holds details about calls to the Checker method.
 		Checker []struct {
@@ -120,11 +171,31 @@ type DataStorerMock struct {
 			// CheckState is the checkState argument value.
 			CheckState *healthcheck.CheckState
 		}
+		// ClaimOutboxMessage holds details about calls to the ClaimOutboxMessage method.
+		ClaimOutboxMessage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+			// ClaimedBefore is the claimedBefore argument value.
+			ClaimedBefore time.Time
+		}
 		// Close holds details about calls to the Close method.
 		Close []struct {
 			// ContextMoqParam is the contextMoqParam argument value.
 			ContextMoqParam context.Context
 		}
+		// DeleteUploadedFile holds details about calls to the DeleteUploadedFile method.
+		DeleteUploadedFile []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// AliasName is the aliasName argument value.
+			AliasName string
+			// ETag is the eTag argument value.
+			ETag string
+		}
 		// GetJob holds details about calls to the GetJob method.
 		GetJob []struct {
 			// Ctx is the ctx argument value.
@@ -132,23 +203,89 @@ type DataStorerMock struct {
 			// JobID is the jobID argument value.
 			JobID string
 		}
+		// GetJobByIdempotencyKey holds details about calls to the GetJobByIdempotencyKey method.
+		GetJobByIdempotencyKey []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Key is the key argument value.
+			Key string
+		}
+		// GetJobFields holds details about calls to the GetJobFields method.
+		GetJobFields []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// Fields is the fields argument value.
+			Fields []string
+		}
 		// GetJobs holds details about calls to the GetJobs method.
 		GetJobs []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// Filters is the filters argument value.
-			Filters []string
+			// Filter is the filter argument value.
+			Filter *models.JobFilter
+			// Sort is the sort argument value.
+			Sort []models.JobSort
+			// Fields is the fields argument value.
+			Fields []string
 			// Offset is the offset argument value.
 			Offset int
 			// Limit is the limit argument value.
 			Limit int
 		}
-		// UnlockInstance holds details about calls to the UnlockInstance method.
-		UnlockInstance []struct {
+		// GetJobsAfter holds details about calls to the GetJobsAfter method.
+		GetJobsAfter []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Filter is the filter argument value.
+			Filter *models.JobFilter
+			// Cursor is the cursor argument value.
+			Cursor *models.JobCursor
+			// Fields is the fields argument value.
+			Fields []string
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// GetPendingOutboxMessages holds details about calls to the GetPendingOutboxMessages method.
+		GetPendingOutboxMessages []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// LockID is the lockID argument value.
-			LockID string
+			// CreatedBefore is the createdBefore argument value.
+			CreatedBefore time.Time
+			// ClaimedBefore is the claimedBefore argument value.
+			ClaimedBefore time.Time
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// IncreaseOutboxMessageAttempts holds details about calls to the IncreaseOutboxMessageAttempts method.
+		IncreaseOutboxMessageAttempts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
+		// IncreaseProcessedInstance holds details about calls to the IncreaseProcessedInstance method.
+		IncreaseProcessedInstance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// InstanceID is the instanceID argument value.
+			InstanceID string
+			// Dimension is the dimension argument value.
+			Dimension string
+			// ETag is the eTag argument value.
+			ETag string
+		}
+		// RetryJob holds details about calls to the RetryJob method.
+		RetryJob []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID string
+			// Update is the update argument value.
+			Update *models.Job
 		}
 		// UpdateJob holds details about calls to the UpdateJob method.
 		UpdateJob []struct {
@@ -158,62 +295,37 @@ type DataStorerMock struct {
 			JobID string
 			// Update is the update argument value.
 			Update *models.Job
+			// ETag is the eTag argument value.
+			ETag string
 		}
-		// UpdateProcessedInstance holds details about calls to the UpdateProcessedInstance method.
-		UpdateProcessedInstance []struct {
+		// UpdateOutboxMessageState holds details about calls to the UpdateOutboxMessageState method.
+		UpdateOutboxMessageState []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// ID is the id argument value.
 			ID string
-			// ProcInstances is the procInstances argument value.
-			ProcInstances []models.ProcessedInstances
+			// State is the state argument value.
+			State string
 		}
 	}
-	lockAcquireInstanceLock     sync.RWMutex
-	lockAddJob                  sync.RWMutex
-	lockAddUploadedFile         sync.RWMutex
-	lockChecker                 sync.RWMutex
-	lockClose                   sync.RWMutex
-	lockGetJob                  sync.RWMutex
-	lockGetJobs                 sync.RWMutex
-	lockUnlockInstance          sync.RWMutex
-	lockUpdateJob               sync.RWMutex
-	lockUpdateProcessedInstance sync.RWMutex
-}
-
-// AcquireInstanceLock calls AcquireInstanceLockFunc.
-func (mock *DataStorerMock) AcquireInstanceLock(ctx context.Context, jobID string) (string, error) {
-	if mock.AcquireInstanceLockFunc == nil {
-		panic("DataStorerMock.AcquireInstanceLockFunc: method is nil but DataStorer.AcquireInstanceLock was just called")
-	}
-	callInfo := struct {
-		Ctx   context.Context
-		JobID string
-	}{
-		Ctx:   ctx,
-		JobID: jobID,
-	}
-	mock.lockAcquireInstanceLock.Lock()
-	mock.calls.AcquireInstanceLock = append(mock.calls.AcquireInstanceLock, callInfo)
-	mock.lockAcquireInstanceLock.Unlock()
-	return mock.AcquireInstanceLockFunc(ctx, jobID)
-}
-
-// AcquireInstanceLockCalls gets all the calls that were made to AcquireInstanceLock.
-// Check the length with:
-//     len(mockedDataStorer.AcquireInstanceLockCalls())
-func (mock *DataStorerMock) AcquireInstanceLockCalls() []struct {
-	Ctx   context.Context
-	JobID string
-} {
-	var calls []struct {
-		Ctx   context.Context
-		JobID string
-	}
-	mock.lockAcquireInstanceLock.RLock()
-	calls = mock.calls.AcquireInstanceLock
-	mock.lockAcquireInstanceLock.RUnlock()
-	return calls
+	lockAddJob                        sync.RWMutex
+	lockAddOutboxMessage              sync.RWMutex
+	lockAddUploadedFile               sync.RWMutex
+	lockChecker                       sync.RWMutex
+	lockClaimOutboxMessage            sync.RWMutex
+	lockClose                         sync.RWMutex
+	lockDeleteUploadedFile            sync.RWMutex
+	lockGetJob                        sync.RWMutex
+	lockGetJobByIdempotencyKey        sync.RWMutex
+	lockGetJobFields                  sync.RWMutex
+	lockGetJobs                       sync.RWMutex
+	lockGetJobsAfter                  sync.RWMutex
+	lockGetPendingOutboxMessages      sync.RWMutex
+	lockIncreaseOutboxMessageAttempts sync.RWMutex
+	lockIncreaseProcessedInstance     sync.RWMutex
+	lockRetryJob                      sync.RWMutex
+	lockUpdateJob                     sync.RWMutex
+	lockUpdateOutboxMessageState      sync.RWMutex
 }
 
 // AddJob calls AddJobFunc.
@@ -251,8 +363,43 @@ func (mock *DataStorerMock) AddJobCalls() []struct {
 	return calls
 }
 
+// AddOutboxMessage calls AddOutboxMessageFunc.
+func (mock *DataStorerMock) AddOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	if mock.AddOutboxMessageFunc == nil {
+		panic("DataStorerMock.AddOutboxMessageFunc: method is nil but DataStorer.AddOutboxMessage was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Message *models.OutboxMessage
+	}{
+		Ctx:     ctx,
+		Message: message,
+	}
+	mock.lockAddOutboxMessage.Lock()
+	mock.calls.AddOutboxMessage = append(mock.calls.AddOutboxMessage, callInfo)
+	mock.lockAddOutboxMessage.Unlock()
+	return mock.AddOutboxMessageFunc(ctx, message)
+}
+
+// AddOutboxMessageCalls gets all the calls that were made to AddOutboxMessage.
+// Check the length with:
+//     len(mockedDataStorer.AddOutboxMessageCalls())
+func (mock *DataStorerMock) AddOutboxMessageCalls() []struct {
+	Ctx     context.Context
+	Message *models.OutboxMessage
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Message *models.OutboxMessage
+	}
+	mock.lockAddOutboxMessage.RLock()
+	calls = mock.calls.AddOutboxMessage
+	mock.lockAddOutboxMessage.RUnlock()
+	return calls
+}
+
 // AddUploadedFile calls AddUploadedFileFunc.
-func (mock *DataStorerMock) AddUploadedFile(ctx context.Context, jobID string, message *models.UploadedFile) error {
+func (mock *DataStorerMock) AddUploadedFile(ctx context.Context, jobID string, message *models.UploadedFile, eTag string) error {
 	if mock.AddUploadedFileFunc == nil {
 		panic("DataStorerMock.AddUploadedFileFunc: method is nil but DataStorer.AddUploadedFile was just called")
 	}
@@ -260,15 +407,17 @@ func (mock *DataStorerMock) AddUploadedFile(ctx context.Context, jobID string, m
 		Ctx     context.Context
 		JobID   string
 		Message *models.UploadedFile
+		ETag    string
 	}{
 		Ctx:     ctx,
 		JobID:   jobID,
 		Message: message,
+		ETag:    eTag,
 	}
 	mock.lockAddUploadedFile.Lock()
 	mock.calls.AddUploadedFile = append(mock.calls.AddUploadedFile, callInfo)
 	mock.lockAddUploadedFile.Unlock()
-	return mock.AddUploadedFileFunc(ctx, jobID, message)
+	return mock.AddUploadedFileFunc(ctx, jobID, message, eTag)
 }
 
 // AddUploadedFileCalls gets all the calls that were made to AddUploadedFile.
@@ -278,11 +427,13 @@ func (mock *DataStorerMock) AddUploadedFileCalls() []struct {
 	Ctx     context.Context
 	JobID   string
 	Message *models.UploadedFile
+	ETag    string
 } {
 	var calls []struct {
 		Ctx     context.Context
 		JobID   string
 		Message *models.UploadedFile
+		ETag    string
 	}
 	mock.lockAddUploadedFile.RLock()
 	calls = mock.calls.AddUploadedFile
@@ -325,6 +476,45 @@ func (mock *DataStorerMock) CheckerCalls() []struct {
 	return calls
 }
 
+// ClaimOutboxMessage calls ClaimOutboxMessageFunc.
+func (mock *DataStorerMock) ClaimOutboxMessage(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+	if mock.ClaimOutboxMessageFunc == nil {
+		panic("DataStorerMock.ClaimOutboxMessageFunc: method is nil but DataStorer.ClaimOutboxMessage was just called")
+	}
+	callInfo := struct {
+		Ctx           context.Context
+		ID            string
+		ClaimedBefore time.Time
+	}{
+		Ctx:           ctx,
+		ID:            id,
+		ClaimedBefore: claimedBefore,
+	}
+	mock.lockClaimOutboxMessage.Lock()
+	mock.calls.ClaimOutboxMessage = append(mock.calls.ClaimOutboxMessage, callInfo)
+	mock.lockClaimOutboxMessage.Unlock()
+	return mock.ClaimOutboxMessageFunc(ctx, id, claimedBefore)
+}
+
+// ClaimOutboxMessageCalls gets all the calls that were made to ClaimOutboxMessage.
+// Check the length with:
+//     len(mockedDataStorer.ClaimOutboxMessageCalls())
+func (mock *DataStorerMock) ClaimOutboxMessageCalls() []struct {
+	Ctx           context.Context
+	ID            string
+	ClaimedBefore time.Time
+} {
+	var calls []struct {
+		Ctx           context.Context
+		ID            string
+		ClaimedBefore time.Time
+	}
+	mock.lockClaimOutboxMessage.RLock()
+	calls = mock.calls.ClaimOutboxMessage
+	mock.lockClaimOutboxMessage.RUnlock()
+	return calls
+}
+
 // Close calls CloseFunc.
 func (mock *DataStorerMock) Close(contextMoqParam context.Context) error {
 	if mock.CloseFunc == nil {
@@ -356,6 +546,49 @@ func (mock *DataStorerMock) CloseCalls() []struct {
 	return calls
 }
 
+// DeleteUploadedFile calls DeleteUploadedFileFunc.
+func (mock *DataStorerMock) DeleteUploadedFile(ctx context.Context, jobID string, aliasName string, eTag string) error {
+	if mock.DeleteUploadedFileFunc == nil {
+		panic("DataStorerMock.DeleteUploadedFileFunc: method is nil but DataStorer.DeleteUploadedFile was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		JobID     string
+		AliasName string
+		ETag      string
+	}{
+		Ctx:       ctx,
+		JobID:     jobID,
+		AliasName: aliasName,
+		ETag:      eTag,
+	}
+	mock.lockDeleteUploadedFile.Lock()
+	mock.calls.DeleteUploadedFile = append(mock.calls.DeleteUploadedFile, callInfo)
+	mock.lockDeleteUploadedFile.Unlock()
+	return mock.DeleteUploadedFileFunc(ctx, jobID, aliasName, eTag)
+}
+
+// DeleteUploadedFileCalls gets all the calls that were made to DeleteUploadedFile.
+// Check the length with:
+//     len(mockedDataStorer.DeleteUploadedFileCalls())
+func (mock *DataStorerMock) DeleteUploadedFileCalls() []struct {
+	Ctx       context.Context
+	JobID     string
+	AliasName string
+	ETag      string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		JobID     string
+		AliasName string
+		ETag      string
+	}
+	mock.lockDeleteUploadedFile.RLock()
+	calls = mock.calls.DeleteUploadedFile
+	mock.lockDeleteUploadedFile.RUnlock()
+	return calls
+}
+
 // GetJob calls GetJobFunc.
 func (mock *DataStorerMock) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
 	if mock.GetJobFunc == nil {
@@ -391,42 +624,124 @@ func (mock *DataStorerMock) GetJobCalls() []struct {
 	return calls
 }
 
+// GetJobByIdempotencyKey calls GetJobByIdempotencyKeyFunc.
+func (mock *DataStorerMock) GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error) {
+	if mock.GetJobByIdempotencyKeyFunc == nil {
+		panic("DataStorerMock.GetJobByIdempotencyKeyFunc: method is nil but DataStorer.GetJobByIdempotencyKey was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Key string
+	}{
+		Ctx: ctx,
+		Key: key,
+	}
+	mock.lockGetJobByIdempotencyKey.Lock()
+	mock.calls.GetJobByIdempotencyKey = append(mock.calls.GetJobByIdempotencyKey, callInfo)
+	mock.lockGetJobByIdempotencyKey.Unlock()
+	return mock.GetJobByIdempotencyKeyFunc(ctx, key)
+}
+
+// GetJobByIdempotencyKeyCalls gets all the calls that were made to GetJobByIdempotencyKey.
+// Check the length with:
+//     len(mockedDataStorer.GetJobByIdempotencyKeyCalls())
+func (mock *DataStorerMock) GetJobByIdempotencyKeyCalls() []struct {
+	Ctx context.Context
+	Key string
+} {
+	var calls []struct {
+		Ctx context.Context
+		Key string
+	}
+	mock.lockGetJobByIdempotencyKey.RLock()
+	calls = mock.calls.GetJobByIdempotencyKey
+	mock.lockGetJobByIdempotencyKey.RUnlock()
+	return calls
+}
+
+// GetJobFields calls GetJobFieldsFunc.
+func (mock *DataStorerMock) GetJobFields(ctx context.Context, jobID string, fields []string) (*models.Job, error) {
+	if mock.GetJobFieldsFunc == nil {
+		panic("DataStorerMock.GetJobFieldsFunc: method is nil but DataStorer.GetJobFields was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		JobID  string
+		Fields []string
+	}{
+		Ctx:    ctx,
+		JobID:  jobID,
+		Fields: fields,
+	}
+	mock.lockGetJobFields.Lock()
+	mock.calls.GetJobFields = append(mock.calls.GetJobFields, callInfo)
+	mock.lockGetJobFields.Unlock()
+	return mock.GetJobFieldsFunc(ctx, jobID, fields)
+}
+
+// GetJobFieldsCalls gets all the calls that were made to GetJobFields.
+// Check the length with:
+//     len(mockedDataStorer.GetJobFieldsCalls())
+func (mock *DataStorerMock) GetJobFieldsCalls() []struct {
+	Ctx    context.Context
+	JobID  string
+	Fields []string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		JobID  string
+		Fields []string
+	}
+	mock.lockGetJobFields.RLock()
+	calls = mock.calls.GetJobFields
+	mock.lockGetJobFields.RUnlock()
+	return calls
+}
+
 // GetJobs calls GetJobsFunc.
-func (mock *DataStorerMock) GetJobs(ctx context.Context, filters []string, offset int, limit int) (*models.JobResults, error) {
+func (mock *DataStorerMock) GetJobs(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
 	if mock.GetJobsFunc == nil {
 		panic("DataStorerMock.GetJobsFunc: method is nil but DataStorer.GetJobs was just called")
 	}
 	callInfo := struct {
-		Ctx     context.Context
-		Filters []string
-		Offset  int
-		Limit   int
+		Ctx    context.Context
+		Filter *models.JobFilter
+		Sort   []models.JobSort
+		Fields []string
+		Offset int
+		Limit  int
 	}{
-		Ctx:     ctx,
-		Filters: filters,
-		Offset:  offset,
-		Limit:   limit,
+		Ctx:    ctx,
+		Filter: filter,
+		Sort:   sort,
+		Fields: fields,
+		Offset: offset,
+		Limit:  limit,
 	}
 	mock.lockGetJobs.Lock()
 	mock.calls.GetJobs = append(mock.calls.GetJobs, callInfo)
 	mock.lockGetJobs.Unlock()
-	return mock.GetJobsFunc(ctx, filters, offset, limit)
+	return mock.GetJobsFunc(ctx, filter, sort, fields, offset, limit)
 }
 
 // GetJobsCalls gets all the calls that were made to GetJobs.
 // Check the length with:
 //     len(mockedDataStorer.GetJobsCalls())
 func (mock *DataStorerMock) GetJobsCalls() []struct {
-	Ctx     context.Context
-	Filters []string
-	Offset  int
-	Limit   int
+	Ctx    context.Context
+	Filter *models.JobFilter
+	Sort   []models.JobSort
+	Fields []string
+	Offset int
+	Limit  int
 } {
 	var calls []struct {
-		Ctx     context.Context
-		Filters []string
-		Offset  int
-		Limit   int
+		Ctx    context.Context
+		Filter *models.JobFilter
+		Sort   []models.JobSort
+		Fields []string
+		Offset int
+		Limit  int
 	}
 	mock.lockGetJobs.RLock()
 	calls = mock.calls.GetJobs
@@ -434,43 +749,219 @@ func (mock *DataStorerMock) GetJobsCalls() []struct {
 	return calls
 }
 
-// UnlockInstance calls UnlockInstanceFunc.
-func (mock *DataStorerMock) UnlockInstance(ctx context.Context, lockID string) {
-	if mock.UnlockInstanceFunc == nil {
-		panic("DataStorerMock.UnlockInstanceFunc: method is nil but DataStorer.UnlockInstance was just called")
+// GetJobsAfter calls GetJobsAfterFunc.
+func (mock *DataStorerMock) GetJobsAfter(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error) {
+	if mock.GetJobsAfterFunc == nil {
+		panic("DataStorerMock.GetJobsAfterFunc: method is nil but DataStorer.GetJobsAfter was just called")
 	}
 	callInfo := struct {
 		Ctx    context.Context
-		LockID string
+		Filter *models.JobFilter
+		Cursor *models.JobCursor
+		Fields []string
+		Limit  int
 	}{
 		Ctx:    ctx,
-		LockID: lockID,
+		Filter: filter,
+		Cursor: cursor,
+		Fields: fields,
+		Limit:  limit,
 	}
-	mock.lockUnlockInstance.Lock()
-	mock.calls.UnlockInstance = append(mock.calls.UnlockInstance, callInfo)
-	mock.lockUnlockInstance.Unlock()
-	mock.UnlockInstanceFunc(ctx, lockID)
+	mock.lockGetJobsAfter.Lock()
+	mock.calls.GetJobsAfter = append(mock.calls.GetJobsAfter, callInfo)
+	mock.lockGetJobsAfter.Unlock()
+	return mock.GetJobsAfterFunc(ctx, filter, cursor, fields, limit)
 }
 
-// UnlockInstanceCalls gets all the calls that were made to UnlockInstance.
+// GetJobsAfterCalls gets all the calls that were made to GetJobsAfter.
 // Check the length with:
-//     len(mockedDataStorer.UnlockInstanceCalls())
-func (mock *DataStorerMock) UnlockInstanceCalls() []struct {
+//     len(mockedDataStorer.GetJobsAfterCalls())
+func (mock *DataStorerMock) GetJobsAfterCalls() []struct {
 	Ctx    context.Context
-	LockID string
+	Filter *models.JobFilter
+	Cursor *models.JobCursor
+	Fields []string
+	Limit  int
 } {
 	var calls []struct {
 		Ctx    context.Context
-		LockID string
+		Filter *models.JobFilter
+		Cursor *models.JobCursor
+		Fields []string
+		Limit  int
 	}
-	mock.lockUnlockInstance.RLock()
-	calls = mock.calls.UnlockInstance
-	mock.lockUnlockInstance.RUnlock()
+	mock.lockGetJobsAfter.RLock()
+	calls = mock.calls.GetJobsAfter
+	mock.lockGetJobsAfter.RUnlock()
+	return calls
+}
+
+// GetPendingOutboxMessages calls GetPendingOutboxMessagesFunc.
+func (mock *DataStorerMock) GetPendingOutboxMessages(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+	if mock.GetPendingOutboxMessagesFunc == nil {
+		panic("DataStorerMock.GetPendingOutboxMessagesFunc: method is nil but DataStorer.GetPendingOutboxMessages was just called")
+	}
+	callInfo := struct {
+		Ctx           context.Context
+		CreatedBefore time.Time
+		ClaimedBefore time.Time
+		Limit         int
+	}{
+		Ctx:           ctx,
+		CreatedBefore: createdBefore,
+		ClaimedBefore: claimedBefore,
+		Limit:         limit,
+	}
+	mock.lockGetPendingOutboxMessages.Lock()
+	mock.calls.GetPendingOutboxMessages = append(mock.calls.GetPendingOutboxMessages, callInfo)
+	mock.lockGetPendingOutboxMessages.Unlock()
+	return mock.GetPendingOutboxMessagesFunc(ctx, createdBefore, claimedBefore, limit)
+}
+
+// GetPendingOutboxMessagesCalls gets all the calls that were made to GetPendingOutboxMessages.
+// Check the length with:
+//     len(mockedDataStorer.GetPendingOutboxMessagesCalls())
+func (mock *DataStorerMock) GetPendingOutboxMessagesCalls() []struct {
+	Ctx           context.Context
+	CreatedBefore time.Time
+	ClaimedBefore time.Time
+	Limit         int
+} {
+	var calls []struct {
+		Ctx           context.Context
+		CreatedBefore time.Time
+		ClaimedBefore time.Time
+		Limit         int
+	}
+	mock.lockGetPendingOutboxMessages.RLock()
+	calls = mock.calls.GetPendingOutboxMessages
+	mock.lockGetPendingOutboxMessages.RUnlock()
+	return calls
+}
+
+// IncreaseOutboxMessageAttempts calls IncreaseOutboxMessageAttemptsFunc.
+func (mock *DataStorerMock) IncreaseOutboxMessageAttempts(ctx context.Context, id string) error {
+	if mock.IncreaseOutboxMessageAttemptsFunc == nil {
+		panic("DataStorerMock.IncreaseOutboxMessageAttemptsFunc: method is nil but DataStorer.IncreaseOutboxMessageAttempts was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockIncreaseOutboxMessageAttempts.Lock()
+	mock.calls.IncreaseOutboxMessageAttempts = append(mock.calls.IncreaseOutboxMessageAttempts, callInfo)
+	mock.lockIncreaseOutboxMessageAttempts.Unlock()
+	return mock.IncreaseOutboxMessageAttemptsFunc(ctx, id)
+}
+
+// IncreaseOutboxMessageAttemptsCalls gets all the calls that were made to IncreaseOutboxMessageAttempts.
+// Check the length with:
+//     len(mockedDataStorer.IncreaseOutboxMessageAttemptsCalls())
+func (mock *DataStorerMock) IncreaseOutboxMessageAttemptsCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockIncreaseOutboxMessageAttempts.RLock()
+	calls = mock.calls.IncreaseOutboxMessageAttempts
+	mock.lockIncreaseOutboxMessageAttempts.RUnlock()
+	return calls
+}
+
+// IncreaseProcessedInstance calls IncreaseProcessedInstanceFunc.
+func (mock *DataStorerMock) IncreaseProcessedInstance(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+	if mock.IncreaseProcessedInstanceFunc == nil {
+		panic("DataStorerMock.IncreaseProcessedInstanceFunc: method is nil but DataStorer.IncreaseProcessedInstance was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		JobID      string
+		InstanceID string
+		Dimension  string
+		ETag       string
+	}{
+		Ctx:        ctx,
+		JobID:      jobID,
+		InstanceID: instanceID,
+		Dimension:  dimension,
+		ETag:       eTag,
+	}
+	mock.lockIncreaseProcessedInstance.Lock()
+	mock.calls.IncreaseProcessedInstance = append(mock.calls.IncreaseProcessedInstance, callInfo)
+	mock.lockIncreaseProcessedInstance.Unlock()
+	return mock.IncreaseProcessedInstanceFunc(ctx, jobID, instanceID, dimension, eTag)
+}
+
+// IncreaseProcessedInstanceCalls gets all the calls that were made to IncreaseProcessedInstance.
+// Check the length with:
+//     len(mockedDataStorer.IncreaseProcessedInstanceCalls())
+func (mock *DataStorerMock) IncreaseProcessedInstanceCalls() []struct {
+	Ctx        context.Context
+	JobID      string
+	InstanceID string
+	Dimension  string
+	ETag       string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		JobID      string
+		InstanceID string
+		Dimension  string
+		ETag       string
+	}
+	mock.lockIncreaseProcessedInstance.RLock()
+	calls = mock.calls.IncreaseProcessedInstance
+	mock.lockIncreaseProcessedInstance.RUnlock()
+	return calls
+}
+
+// RetryJob calls RetryJobFunc.
+func (mock *DataStorerMock) RetryJob(ctx context.Context, jobID string, update *models.Job) error {
+	if mock.RetryJobFunc == nil {
+		panic("DataStorerMock.RetryJobFunc: method is nil but DataStorer.RetryJob was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		JobID  string
+		Update *models.Job
+	}{
+		Ctx:    ctx,
+		JobID:  jobID,
+		Update: update,
+	}
+	mock.lockRetryJob.Lock()
+	mock.calls.RetryJob = append(mock.calls.RetryJob, callInfo)
+	mock.lockRetryJob.Unlock()
+	return mock.RetryJobFunc(ctx, jobID, update)
+}
+
+// RetryJobCalls gets all the calls that were made to RetryJob.
+// Check the length with:
+//     len(mockedDataStorer.RetryJobCalls())
+func (mock *DataStorerMock) RetryJobCalls() []struct {
+	Ctx    context.Context
+	JobID  string
+	Update *models.Job
+} {
+	var calls []struct {
+		Ctx    context.Context
+		JobID  string
+		Update *models.Job
+	}
+	mock.lockRetryJob.RLock()
+	calls = mock.calls.RetryJob
+	mock.lockRetryJob.RUnlock()
 	return calls
 }
 
 // UpdateJob calls UpdateJobFunc.
-func (mock *DataStorerMock) UpdateJob(ctx context.Context, jobID string, update *models.Job) error {
+func (mock *DataStorerMock) UpdateJob(ctx context.Context, jobID string, update *models.Job, eTag string) error {
 	if mock.UpdateJobFunc == nil {
 		panic("DataStorerMock.UpdateJobFunc: method is nil but DataStorer.UpdateJob was just called")
 	}
@@ -478,15 +969,17 @@ func (mock *DataStorerMock) UpdateJob(ctx context.Context, jobID string, update
 		Ctx    context.Context
 		JobID  string
 		Update *models.Job
+		ETag   string
 	}{
 		Ctx:    ctx,
 		JobID:  jobID,
 		Update: update,
+		ETag:   eTag,
 	}
 	mock.lockUpdateJob.Lock()
 	mock.calls.UpdateJob = append(mock.calls.UpdateJob, callInfo)
 	mock.lockUpdateJob.Unlock()
-	return mock.UpdateJobFunc(ctx, jobID, update)
+	return mock.UpdateJobFunc(ctx, jobID, update, eTag)
 }
 
 // UpdateJobCalls gets all the calls that were made to UpdateJob.
@@ -496,11 +989,13 @@ func (mock *DataStorerMock) UpdateJobCalls() []struct {
 	Ctx    context.Context
 	JobID  string
 	Update *models.Job
+	ETag   string
 } {
 	var calls []struct {
 		Ctx    context.Context
 		JobID  string
 		Update *models.Job
+		ETag   string
 	}
 	mock.lockUpdateJob.RLock()
 	calls = mock.calls.UpdateJob
@@ -508,41 +1003,41 @@ func (mock *DataStorerMock) UpdateJobCalls() []struct {
 	return calls
 }
 
-// UpdateProcessedInstance calls UpdateProcessedInstanceFunc.
-func (mock *DataStorerMock) UpdateProcessedInstance(ctx context.Context, id string, procInstances []models.ProcessedInstances) error {
-	if mock.UpdateProcessedInstanceFunc == nil {
-		panic("DataStorerMock.UpdateProcessedInstanceFunc: method is nil but DataStorer.UpdateProcessedInstance was just called")
+// UpdateOutboxMessageState calls UpdateOutboxMessageStateFunc.
+func (mock *DataStorerMock) UpdateOutboxMessageState(ctx context.Context, id string, state string) error {
+	if mock.UpdateOutboxMessageStateFunc == nil {
+		panic("DataStorerMock.UpdateOutboxMessageStateFunc: method is nil but DataStorer.UpdateOutboxMessageState was just called")
 	}
 	callInfo := struct {
-		Ctx           context.Context
-		ID            string
-		ProcInstances []models.ProcessedInstances
+		Ctx   context.Context
+		ID    string
+		State string
 	}{
-		Ctx:           ctx,
-		ID:            id,
-		ProcInstances: procInstances,
+		Ctx:   ctx,
+		ID:    id,
+		State: state,
 	}
-	mock.lockUpdateProcessedInstance.Lock()
-	mock.calls.UpdateProcessedInstance = append(mock.calls.UpdateProcessedInstance, callInfo)
-	mock.lockUpdateProcessedInstance.Unlock()
-	return mock.UpdateProcessedInstanceFunc(ctx, id, procInstances)
+	mock.lockUpdateOutboxMessageState.Lock()
+	mock.calls.UpdateOutboxMessageState = append(mock.calls.UpdateOutboxMessageState, callInfo)
+	mock.lockUpdateOutboxMessageState.Unlock()
+	return mock.UpdateOutboxMessageStateFunc(ctx, id, state)
 }
 
-// UpdateProcessedInstanceCalls gets all the calls that were made to UpdateProcessedInstance.
+// UpdateOutboxMessageStateCalls gets all the calls that were made to UpdateOutboxMessageState.
 // Check the length with:
-//     len(mockedDataStorer.UpdateProcessedInstanceCalls())
-func (mock *DataStorerMock) UpdateProcessedInstanceCalls() []struct {
-	Ctx           context.Context
-	ID            string
-	ProcInstances []models.ProcessedInstances
+//     len(mockedDataStorer.UpdateOutboxMessageStateCalls())
+func (mock *DataStorerMock) UpdateOutboxMessageStateCalls() []struct {
+	Ctx   context.Context
+	ID    string
+	State string
 } {
 	var calls []struct {
-		Ctx           context.Context
-		ID            string
-		ProcInstances []models.ProcessedInstances
+		Ctx   context.Context
+		ID    string
+		State string
 	}
-	mock.lockUpdateProcessedInstance.RLock()
-	calls = mock.calls.UpdateProcessedInstance
-	mock.lockUpdateProcessedInstance.RUnlock()
+	mock.lockUpdateOutboxMessageState.RLock()
+	calls = mock.calls.UpdateOutboxMessageState
+	mock.lockUpdateOutboxMessageState.RUnlock()
 	return calls
 }
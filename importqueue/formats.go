@@ -0,0 +1,121 @@
+package importqueue
+
+import (
+	"errors"
+
+	"github.com/ONSdigital/dp-import-api/config"
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/dp-import-api/schema"
+	"github.com/ONSdigital/dp-import/events"
+)
+
+// block of constants corresponding to possible job formats
+const (
+	formatV4                          = "v4"
+	formatDataBaker                   = "databaker"
+	formatCantabularBlob              = "cantabular_blob"
+	formatCantabularTable             = "cantabular_table"
+	formatCantabularFlexibleTable     = "cantabular_flexible_table"
+	formatCantabularMultiVariateTable = "cantabular_multivariate_table"
+)
+
+// NewDefaultRegistry returns a Registry with the dispatchers of every job format supported by the import API,
+// producing to the topics defined in the provided kafka configuration
+func NewDefaultRegistry(kafkaCfg *config.KafkaConfig) *Registry {
+	registry := NewRegistry()
+
+	registry.Register(formatV4, &Dispatcher{
+		Topic:    kafkaCfg.InputFileAvailableTopic,
+		Schema:   events.InputFileAvailableSchema,
+		Validate: validateV4,
+		Events:   v4Events,
+	})
+
+	registry.Register(formatDataBaker, &Dispatcher{
+		Topic:    kafkaCfg.DatabakerImportTopic,
+		Schema:   schema.DataBaker,
+		Validate: validateDataBaker,
+		Events:   dataBakerEvents,
+	})
+
+	cantabular := &Dispatcher{
+		Topic:    kafkaCfg.CantabularDatasetInstanceStartedTopic,
+		Schema:   schema.CantabularDatasetInstanceStarted,
+		Validate: validateCantabular,
+		Events:   cantabularEvents,
+	}
+	registry.Register(formatCantabularBlob, cantabular)
+	registry.Register(formatCantabularTable, cantabular)
+	registry.Register(formatCantabularFlexibleTable, cantabular)
+	registry.Register(formatCantabularMultiVariateTable, cantabular)
+
+	return registry
+}
+
+// validateV4 checks that a V4 import has an uploaded file mapped to each of its instances
+func validateV4(job *models.ImportData) error {
+	if len(job.InstanceIDs) == 0 {
+		return errors.New("InstanceIds must not be empty")
+	}
+	for _, instanceID := range job.InstanceIDs {
+		if _, err := job.UploadedFileForInstance(instanceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// v4Events builds an input file available event for each instance of a V4 import, with the file mapped to it
+func v4Events(job *models.ImportData) []interface{} {
+	inputFileAvailableEvents := []interface{}{}
+	for _, instanceID := range job.InstanceIDs {
+		file, _ := job.UploadedFileForInstance(instanceID)
+		inputFileAvailableEvents = append(inputFileAvailableEvents, events.InputFileAvailable{
+			JobID:      job.JobID,
+			InstanceID: instanceID,
+			URL:        file.URL,
+		})
+	}
+	return inputFileAvailableEvents
+}
+
+// validateDataBaker checks that a data baker import has the spreadsheets to transform
+func validateDataBaker(job *models.ImportData) error {
+	if job.UploadedFiles == nil || len(*job.UploadedFiles) == 0 {
+		return errors.New("uploaded files must not be empty")
+	}
+	return nil
+}
+
+// dataBakerEvents builds the data baker event of a data baker import
+func dataBakerEvents(job *models.ImportData) []interface{} {
+	return []interface{}{
+		models.DataBakerEvent{
+			JobID: job.JobID,
+		},
+	}
+}
+
+// validateCantabular checks that a Cantabular import has at least one instance
+func validateCantabular(job *models.ImportData) error {
+	if len(job.InstanceIDs) == 0 {
+		return errors.New("InstanceIds must not be empty")
+	}
+	return nil
+}
+
+// cantabularEvents builds a cantabular dataset instance started event for each instance of a Cantabular import,
+// with the dataset ID of the instance
+func cantabularEvents(job *models.ImportData) []interface{} {
+	instanceStartedEvents := []interface{}{}
+	for _, instanceID := range job.InstanceIDs {
+		instanceStartedEvents = append(instanceStartedEvents, models.CantabularDatasetInstanceStartedEvent{
+			RecipeID:       job.Recipe,
+			JobID:          job.JobID,
+			InstanceID:     instanceID,
+			CantabularType: job.Format,
+			DatasetID:      job.DatasetIDForInstance(instanceID),
+		})
+	}
+	return instanceStartedEvents
+}
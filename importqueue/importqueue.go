@@ -3,99 +3,83 @@ package importqueue
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/models"
-	"github.com/ONSdigital/dp-import/events"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
-// block of constants corresponding to possible job formats
-const (
-	formatV4                          = "v4"
-	formatCantabularBlob              = "cantabular_blob"
-	formatCantabularTable             = "cantabular_table"
-	formatCantabularFlexibleTable     = "cantabular_flexible_table"
-	formatCantabularMultiVariateTable = "cantabular_multivariate_table"
-)
-
 // ImportQueue used to send import jobs via kafka topic
 type ImportQueue struct {
-	databakerQueue  chan []byte
-	v4Queue         chan []byte
-	cantabularQueue chan []byte
+	registry *Registry
+	queues   map[string]chan []byte
 }
 
-// CreateImportQueue used to queue data baker evenets and v4 files
-func CreateImportQueue(databakerQueue, v4Queue, cantabularQueue chan []byte) *ImportQueue {
-	return &ImportQueue{databakerQueue: databakerQueue, v4Queue: v4Queue, cantabularQueue: cantabularQueue}
+// CreateImportQueue used to queue the import events of the formats in the provided registry,
+// with the queues (kafka producer output channels) of their topics
+func CreateImportQueue(registry *Registry, queues map[string]chan []byte) *ImportQueue {
+	return &ImportQueue{registry: registry, queues: queues}
 }
 
-// Queue generates a kafka message for an import event, according to the provided job format
+// Queue generates the kafka messages for an import event, according to the dispatcher registered for the job format
 func (q *ImportQueue) Queue(ctx context.Context, job *models.ImportData) error {
 	if job == nil {
 		return errors.New("job not available")
 	}
 
-	switch job.Format {
-	case formatV4:
-		return q.queueV4(ctx, job)
-	case formatCantabularTable, formatCantabularBlob, formatCantabularFlexibleTable, formatCantabularMultiVariateTable:
-		return q.queueCantabular(ctx, job)
-	default:
+	dispatcher, ok := q.registry.Get(job.Format)
+	if !ok {
 		log.Warn(ctx, "unrecognised job format, no action has been taken", log.Data{"job_format": job.Format})
+		return errs.ErrUnsupportedFormat
 	}
-	return nil
-}
 
-// queueV4 generates a kafka message for a V4 import
-func (q *ImportQueue) queueV4(ctx context.Context, job *models.ImportData) error {
-	if q.v4Queue == nil {
-		return errors.New("v4 queue (kafka producer) is not available")
+	queue := q.queues[dispatcher.Topic]
+	if queue == nil {
+		return fmt.Errorf("%s queue (kafka producer) is not available", dispatcher.Topic)
 	}
-	if job.InstanceIDs == nil || len(job.InstanceIDs) != 1 || job.UploadedFiles == nil || len(*job.UploadedFiles) != 1 {
-		return errors.New("InstanceIds and uploaded files must have length 1")
+	if err := q.Validate(job); err != nil {
+		return err
 	}
 
-	inputFileAvailableEvent := events.InputFileAvailable{
-		JobID:      job.JobID,
-		InstanceID: job.InstanceIDs[0],
-		URL:        (*job.UploadedFiles)[0].URL,
+	// all the events are marshalled before any is produced, so that a job is either fully queued or not at all
+	var messages [][]byte
+	for _, event := range dispatcher.Events(job) {
+		bytes, avroError := dispatcher.Schema.Marshal(event)
+		if avroError != nil {
+			return avroError
+		}
+		messages = append(messages, bytes)
+		log.Info(ctx, "producing new import event", log.Data{"event": event, "format": job.Format, "topic": dispatcher.Topic})
 	}
 
-	log.Info(ctx, "producing new input file available event", log.Data{"event": inputFileAvailableEvent, "format": formatV4})
-
-	bytes, avroError := events.InputFileAvailableSchema.Marshal(inputFileAvailableEvent)
-	if avroError != nil {
-		return avroError
+	for _, bytes := range messages {
+		queue <- bytes
 	}
-
-	q.v4Queue <- bytes
 	return nil
 }
 
-// queueCantabular generates a kafka message for a Cantabular import
-func (q *ImportQueue) queueCantabular(ctx context.Context, job *models.ImportData) error {
-	if q.cantabularQueue == nil {
-		return errors.New("cantabular queue (kafka producer) is not available")
+// Validate checks that the import events of a job can be produced by the dispatcher registered for the job format,
+// without producing them. A job that is not valid can never be queued, whatever the number of attempts.
+func (q *ImportQueue) Validate(job *models.ImportData) error {
+	dispatcher, ok := q.registry.Get(job.Format)
+	if !ok {
+		return errs.ErrUnsupportedFormat
 	}
-	if job.InstanceIDs == nil || len(job.InstanceIDs) != 1 {
-		return errors.New("InstanceIds must have length 1")
+	if err := dispatcher.Validate(job); err != nil {
+		return errs.ErrorJobNotImportable(err)
 	}
+	return nil
+}
 
-	event := events.CantabularDatasetInstanceStarted{
-		RecipeID:       job.Recipe,
-		JobID:          job.JobID,
-		InstanceID:     job.InstanceIDs[0],
-		CantabularType: job.Format,
-	}
-
-	log.Info(ctx, "producing new cantabular dataset instance started event", log.Data{"event": event})
-
-	bytes, avroError := events.CantabularDatasetInstanceStartedSchema.Marshal(event)
-	if avroError != nil {
-		return avroError
+// Formats returns the sorted list of job formats that can be queued, which are the formats with an available queue
+func (q *ImportQueue) Formats() []string {
+	formats := []string{}
+	for _, format := range q.registry.Formats() {
+		dispatcher, _ := q.registry.Get(format)
+		if q.queues[dispatcher.Topic] != nil {
+			formats = append(formats, format)
+		}
 	}
-
-	q.cantabularQueue <- bytes
-	return nil
+	return formats
 }
@@ -2,15 +2,34 @@ package importqueue
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/config"
 	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/dp-import-api/schema"
 	"github.com/ONSdigital/dp-import/events"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 const testRecipeID = "b944be78-f56d-409b-9ebd-ab2b77ffe187"
 
+var testKafkaConfig = &config.KafkaConfig{
+	DatabakerImportTopic:                  "data-bake-job-available",
+	InputFileAvailableTopic:               "input-file-available",
+	CantabularDatasetInstanceStartedTopic: "cantabular-dataset-instance-started",
+}
+
+// createTestImportQueue creates an ImportQueue for the default registry, with the provided queues for its topics
+func createTestImportQueue(databakerQueue, v4Queue, cantabularQueue chan []byte) *ImportQueue {
+	return CreateImportQueue(NewDefaultRegistry(testKafkaConfig), map[string]chan []byte{
+		testKafkaConfig.DatabakerImportTopic:                  databakerQueue,
+		testKafkaConfig.InputFileAvailableTopic:               v4Queue,
+		testKafkaConfig.CantabularDatasetInstanceStartedTopic: cantabularQueue,
+	})
+}
+
 func TestQueueV4File(t *testing.T) {
 	ctx := context.Background()
 
@@ -22,18 +41,18 @@ func TestQueueV4File(t *testing.T) {
 		UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasV4", URL: "s3//aws/000/v4.csv"}}}
 
 	Convey("Given a mocked importQueue without a v4 queue", t, func() {
-		importer := CreateImportQueue(nil, nil, nil)
+		importer := createTestImportQueue(nil, nil, nil)
 
 		Convey("Then importing a valid 'v4' recipe results in the expected error being returned", func() {
 			err := importer.Queue(ctx, &job)
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "v4 queue (kafka producer) is not available")
+			So(err.Error(), ShouldEqual, "input-file-available queue (kafka producer) is not available")
 		})
 	})
 
 	Convey("Given a mocked importQueue with a valid v4 queue", t, func() {
 		v4Queue := make(chan []byte, 1)
-		importer := CreateImportQueue(nil, v4Queue, nil)
+		importer := createTestImportQueue(nil, v4Queue, nil)
 
 		Convey("Then importing an nil 'v4' recipe fails with the expected error", func() {
 			err := importer.Queue(ctx, nil)
@@ -48,7 +67,7 @@ func TestQueueV4File(t *testing.T) {
 				Format:        "v4",
 				UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasV4", URL: "s3//aws/000/v4.csv"}}})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'v4' recipe with empty instanceIDs fails with the expected error", func() {
@@ -58,17 +77,17 @@ func TestQueueV4File(t *testing.T) {
 				Format:        "v4",
 				UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasV4", URL: "s3//aws/000/v4.csv"}}})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
-		Convey("Then importing a 'v4' recipe with multiple instanceIDs fails with the expected error", func() {
+		Convey("Then importing a 'v4' recipe with multiple instanceIDs and a single unmapped uploadedFile fails with the expected error", func() {
 			err := importer.Queue(ctx, &models.ImportData{
 				InstanceIDs:   []string{"1", "2"},
 				Recipe:        testRecipeID,
 				Format:        "v4",
 				UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasV4", URL: "s3//aws/000/v4.csv"}}})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance 1")).Error())
 		})
 
 		Convey("Then importing a 'v4' recipe with nil uploadedFiles fails with the expected error", func() {
@@ -78,7 +97,7 @@ func TestQueueV4File(t *testing.T) {
 				Format:        "v4",
 				UploadedFiles: nil})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance 1")).Error())
 		})
 
 		Convey("Then importing a 'v4' recipe with empty uploadedFiles fails with the expected error", func() {
@@ -88,10 +107,10 @@ func TestQueueV4File(t *testing.T) {
 				Format:        "v4",
 				UploadedFiles: &[]models.UploadedFile{}})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance 1")).Error())
 		})
 
-		Convey("Then importing a 'v4' recipe with multiple uploadedFiles fails with the expected error", func() {
+		Convey("Then importing a 'v4' recipe with multiple unmapped uploadedFiles fails with the expected error", func() {
 			err := importer.Queue(ctx, &models.ImportData{
 				InstanceIDs: []string{"1"},
 				Recipe:      testRecipeID,
@@ -101,7 +120,50 @@ func TestQueueV4File(t *testing.T) {
 					{AliasName: "aliasV42", URL: "s3//aws/000/v42.csv"},
 				}})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds and uploaded files must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance 1")).Error())
+		})
+
+		Convey("Then importing a 'v4' recipe with an instance mapped to a missing uploadedFile fails with the expected error", func() {
+			err := importer.Queue(ctx, &models.ImportData{
+				InstanceIDs: []string{"1", "2"},
+				Recipe:      testRecipeID,
+				Format:      "v4",
+				UploadedFiles: &[]models.UploadedFile{
+					{AliasName: "aliasV41", URL: "s3//aws/000/v41.csv"},
+				},
+				InstanceFiles: []models.InstanceFile{
+					{InstanceID: "1", AliasName: "aliasV41"},
+					{InstanceID: "2", AliasName: "aliasV42"},
+				}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New(`no uploaded file with alias name "aliasV42" for instance 2`)).Error())
+			So(v4Queue, ShouldBeEmpty)
+		})
+
+		Convey("Then importing a 'v4' recipe with multiple mapped instances sends an import event for each instance to the v4 queue", func() {
+			v4Queue := make(chan []byte, 2)
+			importer := createTestImportQueue(nil, v4Queue, nil)
+
+			err := importer.Queue(ctx, &models.ImportData{
+				JobID:       "jobId",
+				InstanceIDs: []string{"1", "2"},
+				Recipe:      testRecipeID,
+				Format:      "v4",
+				UploadedFiles: &[]models.UploadedFile{
+					{AliasName: "aliasV42", URL: "s3//aws/000/v42.csv"},
+					{AliasName: "aliasV41", URL: "s3//aws/000/v41.csv"},
+				},
+				InstanceFiles: []models.InstanceFile{
+					{InstanceID: "1", AliasName: "aliasV41"},
+					{InstanceID: "2", AliasName: "aliasV42"},
+				}})
+			So(err, ShouldBeNil)
+
+			var first, second events.InputFileAvailable
+			So(events.InputFileAvailableSchema.Unmarshal(<-v4Queue, &first), ShouldBeNil)
+			So(events.InputFileAvailableSchema.Unmarshal(<-v4Queue, &second), ShouldBeNil)
+			So(first, ShouldResemble, events.InputFileAvailable{JobID: "jobId", InstanceID: "1", URL: "s3//aws/000/v41.csv"})
+			So(second, ShouldResemble, events.InputFileAvailable{JobID: "jobId", InstanceID: "2", URL: "s3//aws/000/v42.csv"})
 		})
 
 		Convey("Then importing a valid 'v4' recipe sends the expected import event to the v4 queue", func() {
@@ -123,11 +185,72 @@ func TestQueueV4File(t *testing.T) {
 	})
 }
 
+func TestQueueDataBakerFile(t *testing.T) {
+	ctx := context.Background()
+
+	job := models.ImportData{
+		JobID:         "jobId",
+		InstanceIDs:   []string{"1"},
+		Recipe:        testRecipeID,
+		Format:        "databaker",
+		UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasDataBaker", URL: "s3//aws/000/spreadsheet.xlsx"}}}
+
+	Convey("Given a mocked importQueue without a databaker queue", t, func() {
+		importer := createTestImportQueue(nil, nil, nil)
+
+		Convey("Then importing a valid 'databaker' recipe results in the expected error being returned", func() {
+			err := importer.Queue(ctx, &job)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "data-bake-job-available queue (kafka producer) is not available")
+		})
+	})
+
+	Convey("Given a mocked importQueue with a valid databaker queue", t, func() {
+		databakerQueue := make(chan []byte, 1)
+		importer := createTestImportQueue(databakerQueue, nil, nil)
+
+		Convey("Then importing a 'databaker' recipe with nil uploadedFiles fails with the expected error", func() {
+			err := importer.Queue(ctx, &models.ImportData{
+				JobID:         "jobId",
+				Recipe:        testRecipeID,
+				Format:        "databaker",
+				UploadedFiles: nil})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("uploaded files must not be empty")).Error())
+		})
+
+		Convey("Then importing a 'databaker' recipe with empty uploadedFiles fails with the expected error", func() {
+			err := importer.Queue(ctx, &models.ImportData{
+				JobID:         "jobId",
+				Recipe:        testRecipeID,
+				Format:        "databaker",
+				UploadedFiles: &[]models.UploadedFile{}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("uploaded files must not be empty")).Error())
+		})
+
+		Convey("Then importing a valid 'databaker' recipe sends the expected import event to the databaker queue", func() {
+			err := importer.Queue(ctx, &job)
+			So(err, ShouldBeNil)
+
+			bytes := <-databakerQueue
+
+			var dataBakerEvent models.DataBakerEvent
+			err = schema.DataBaker.Unmarshal(bytes, &dataBakerEvent)
+			So(err, ShouldBeNil)
+
+			So(dataBakerEvent, ShouldResemble, models.DataBakerEvent{
+				JobID: job.JobID,
+			})
+		})
+	})
+}
+
 func TestQueueCantabularFile(t *testing.T) {
 	ctx := context.Background()
 
 	Convey("Given a mocked importQueue without a cantabular queue", t, func() {
-		importer := CreateImportQueue(nil, nil, nil)
+		importer := createTestImportQueue(nil, nil, nil)
 
 		Convey("Then importing a 'cantabular_blob' recipe results in the expected error being returned", func() {
 			err := importer.Queue(ctx, &models.ImportData{
@@ -137,7 +260,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Format:      formatCantabularBlob,
 			})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "cantabular queue (kafka producer) is not available")
+			So(err.Error(), ShouldEqual, "cantabular-dataset-instance-started queue (kafka producer) is not available")
 		})
 
 		Convey("Then importing a 'cantabular_table' recipe results in the expected error being returned", func() {
@@ -148,7 +271,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Format:      formatCantabularTable,
 			})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "cantabular queue (kafka producer) is not available")
+			So(err.Error(), ShouldEqual, "cantabular-dataset-instance-started queue (kafka producer) is not available")
 		})
 
 		Convey("Then importing a 'cantabular_flexible_table' recipe results in the expected error being returned", func() {
@@ -159,7 +282,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Format:      formatCantabularFlexibleTable,
 			})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "cantabular queue (kafka producer) is not available")
+			So(err.Error(), ShouldEqual, "cantabular-dataset-instance-started queue (kafka producer) is not available")
 		})
 		Convey("Then importing a 'cantabular_multivariate_table' recipe results in the expected error being returned", func() {
 			err := importer.Queue(ctx, &models.ImportData{
@@ -169,14 +292,14 @@ func TestQueueCantabularFile(t *testing.T) {
 				Format:      formatCantabularMultiVariateTable,
 			})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "cantabular queue (kafka producer) is not available")
+			So(err.Error(), ShouldEqual, "cantabular-dataset-instance-started queue (kafka producer) is not available")
 		})
 
 	})
 
 	Convey("Given a mocked importQueue with a valid cantabular queue", t, func() {
 		cantabularQueue := make(chan []byte, 1)
-		importer := CreateImportQueue(nil, nil, cantabularQueue)
+		importer := createTestImportQueue(nil, nil, cantabularQueue)
 
 		Convey("Then importing an nil 'cantabular' recipe fails with the expected error", func() {
 			err := importer.Queue(ctx, nil)
@@ -190,7 +313,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularBlob})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'cantabular_table' recipe with nil instanceIDs fails with the expected error", func() {
@@ -199,7 +322,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularTable})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'cantabular_flexible_table' recipe with nil instanceIDs fails with the expected error", func() {
@@ -208,7 +331,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularFlexibleTable})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'cantabular_blob' recipe with empty instanceIDs fails with the expected error", func() {
@@ -217,7 +340,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularBlob})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'cantabular_table' recipe with empty instanceIDs fails with the expected error", func() {
@@ -226,7 +349,7 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularTable})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
 		Convey("Then importing a 'cantabular_flexible_table' recipe with empty instanceIDs fails with the expected error", func() {
@@ -235,43 +358,41 @@ func TestQueueCantabularFile(t *testing.T) {
 				Recipe:      testRecipeID,
 				Format:      formatCantabularFlexibleTable})
 			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			So(err.Error(), ShouldEqual, errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty")).Error())
 		})
 
-		Convey("Then importing a 'cantabular_blob' recipe with multiple instanceIDs fails with the expected error", func() {
-			err := importer.Queue(ctx, &models.ImportData{
-				InstanceIDs: []string{"1", "2"},
-				Recipe:      testRecipeID,
-				Format:      formatCantabularBlob})
-			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
-		})
+		Convey("Then importing a 'cantabular_table' recipe with multiple instanceIDs sends an import event for each instance, with its dataset ID, to the cantabular queue", func() {
+			cantabularQueue := make(chan []byte, 2)
+			importer := createTestImportQueue(nil, nil, cantabularQueue)
 
-		Convey("Then importing a 'cantabular_table' recipe with multiple instanceIDs fails with the expected error", func() {
-			err := importer.Queue(ctx, &models.ImportData{
-				InstanceIDs: []string{"1", "2"},
-				Recipe:      testRecipeID,
-				Format:      formatCantabularTable})
-			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
-		})
-
-		Convey("Then importing a 'cantabular_flexible_table' recipe with multiple instanceIDs fails with the expected error", func() {
 			err := importer.Queue(ctx, &models.ImportData{
+				JobID:       "jobId",
 				InstanceIDs: []string{"1", "2"},
 				Recipe:      testRecipeID,
-				Format:      formatCantabularFlexibleTable})
-			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
-		})
+				Format:      formatCantabularTable,
+				InstanceFiles: []models.InstanceFile{
+					{InstanceID: "1", DatasetID: "dataset1"},
+					{InstanceID: "2", DatasetID: "dataset2"},
+				}})
+			So(err, ShouldBeNil)
 
-		Convey("Then importing a 'multivariate_table' recipe with multiple instanceIDs fails with the expected error", func() {
-			err := importer.Queue(ctx, &models.ImportData{
-				InstanceIDs: []string{"1", "2"},
-				Recipe:      testRecipeID,
-				Format:      formatCantabularMultiVariateTable})
-			So(err, ShouldNotBeNil)
-			So(err.Error(), ShouldEqual, "InstanceIds must have length 1")
+			var first, second models.CantabularDatasetInstanceStartedEvent
+			So(schema.CantabularDatasetInstanceStarted.Unmarshal(<-cantabularQueue, &first), ShouldBeNil)
+			So(schema.CantabularDatasetInstanceStarted.Unmarshal(<-cantabularQueue, &second), ShouldBeNil)
+			So(first, ShouldResemble, models.CantabularDatasetInstanceStartedEvent{
+				JobID:          "jobId",
+				RecipeID:       testRecipeID,
+				InstanceID:     "1",
+				CantabularType: formatCantabularTable,
+				DatasetID:      "dataset1",
+			})
+			So(second, ShouldResemble, models.CantabularDatasetInstanceStartedEvent{
+				JobID:          "jobId",
+				RecipeID:       testRecipeID,
+				InstanceID:     "2",
+				CantabularType: formatCantabularTable,
+				DatasetID:      "dataset2",
+			})
 		})
 
 		Convey("Then importing a 'cantabular_blob' recipe with a 'correct' instanceID sends the expected import event to the cantabular queue", func() {
@@ -381,11 +502,45 @@ func TestQueueDefault(t *testing.T) {
 		UploadedFiles: &[]models.UploadedFile{{AliasName: "aliasOther", URL: "s3//aws/000/other.csv"}}}
 
 	Convey("Given a mocked importQueue", t, func() {
-		importer := CreateImportQueue(nil, nil, nil)
+		importer := createTestImportQueue(nil, nil, nil)
 
-		Convey("Then importing an 'other' recipe does not return any error and does not trigger any action", func() {
+		Convey("Then importing an 'other' recipe returns an unsupported format error and does not trigger any action", func() {
 			err := importer.Queue(ctx, &job)
-			So(err, ShouldBeNil)
+			So(err, ShouldEqual, errs.ErrUnsupportedFormat)
+		})
+	})
+}
+
+func TestFormats(t *testing.T) {
+
+	Convey("Given a mocked importQueue without any queue", t, func() {
+		importer := createTestImportQueue(nil, nil, nil)
+
+		Convey("Then no formats are supported", func() {
+			So(importer.Formats(), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a mocked importQueue with v4 and cantabular queues", t, func() {
+		importer := createTestImportQueue(nil, make(chan []byte, 1), make(chan []byte, 1))
+
+		Convey("Then the v4 and cantabular formats are supported", func() {
+			So(importer.Formats(), ShouldResemble, []string{
+				formatCantabularBlob,
+				formatCantabularFlexibleTable,
+				formatCantabularMultiVariateTable,
+				formatCantabularTable,
+				formatV4,
+			})
+		})
+	})
+
+	Convey("Given a mocked importQueue with all the queues", t, func() {
+		importer := createTestImportQueue(make(chan []byte, 1), make(chan []byte, 1), make(chan []byte, 1))
+
+		Convey("Then the databaker format is also supported", func() {
+			So(importer.Formats(), ShouldContain, formatDataBaker)
+			So(importer.Formats(), ShouldHaveLength, 6)
 		})
 	})
 }
@@ -0,0 +1,67 @@
+package importqueue
+
+import (
+	"sort"
+
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/dp-kafka/v2/avro"
+)
+
+// Dispatcher defines how the import events of a job format are produced
+type Dispatcher struct {
+	// Topic is the kafka topic that the events are produced to
+	Topic string
+	// Schema is the avro schema used to marshal the events
+	Schema *avro.Schema
+	// Validate checks that the job can be dispatched, e.g. that it has the expected number of instances and files
+	Validate func(job *models.ImportData) error
+	// Events builds the events to produce for a valid job
+	Events func(job *models.ImportData) []interface{}
+}
+
+// Registry holds the dispatcher registered for each job format
+type Registry struct {
+	dispatchers map[string]*Dispatcher
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{dispatchers: map[string]*Dispatcher{}}
+}
+
+// Register sets the dispatcher for the provided job format, replacing any dispatcher already registered for it
+func (r *Registry) Register(format string, dispatcher *Dispatcher) {
+	r.dispatchers[format] = dispatcher
+}
+
+// Get returns the dispatcher registered for the provided job format, if any
+func (r *Registry) Get(format string) (*Dispatcher, bool) {
+	dispatcher, ok := r.dispatchers[format]
+	return dispatcher, ok
+}
+
+// Formats returns the sorted list of registered job formats
+func (r *Registry) Formats() []string {
+	formats := []string{}
+	for format := range r.dispatchers {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+	return formats
+}
+
+// Topics returns the sorted list of distinct kafka topics of the registered dispatchers
+func (r *Registry) Topics() []string {
+	found := map[string]bool{}
+	topics := []string{}
+	for _, dispatcher := range r.dispatchers {
+		if !found[dispatcher.Topic] {
+			found[dispatcher.Topic] = true
+			topics = append(topics, dispatcher.Topic)
+		}
+	}
+
+	sort.Strings(topics)
+	return topics
+}
@@ -0,0 +1,126 @@
+package importqueue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/dp-import-api/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// customDispatcher produces one data baker event per uploaded file to the 'custom-topic' topic
+var customDispatcher = &Dispatcher{
+	Topic:  "custom-topic",
+	Schema: schema.DataBaker,
+	Validate: func(job *models.ImportData) error {
+		if job.UploadedFiles == nil {
+			return errors.New("uploaded files are required")
+		}
+		return nil
+	},
+	Events: func(job *models.ImportData) []interface{} {
+		var events []interface{}
+		for _, file := range *job.UploadedFiles {
+			events = append(events, models.DataBakerEvent{JobID: job.JobID + "/" + file.AliasName})
+		}
+		return events
+	},
+}
+
+func TestRegistry(t *testing.T) {
+
+	Convey("Given the default registry", t, func() {
+		registry := NewDefaultRegistry(testKafkaConfig)
+
+		Convey("Then every supported format is registered", func() {
+			So(registry.Formats(), ShouldResemble, []string{
+				formatCantabularBlob,
+				formatCantabularFlexibleTable,
+				formatCantabularMultiVariateTable,
+				formatCantabularTable,
+				formatDataBaker,
+				formatV4,
+			})
+		})
+
+		Convey("Then the distinct topics of the dispatchers are returned", func() {
+			So(registry.Topics(), ShouldResemble, []string{
+				"cantabular-dataset-instance-started",
+				"data-bake-job-available",
+				"input-file-available",
+			})
+		})
+
+		Convey("When a new format is registered", func() {
+			registry.Register("custom", customDispatcher)
+
+			Convey("Then its dispatcher and topic are available", func() {
+				dispatcher, ok := registry.Get("custom")
+				So(ok, ShouldBeTrue)
+				So(dispatcher, ShouldEqual, customDispatcher)
+				So(registry.Formats(), ShouldContain, "custom")
+				So(registry.Topics(), ShouldContain, "custom-topic")
+			})
+		})
+
+		Convey("Then an unregistered format is not found", func() {
+			_, ok := registry.Get("other")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestQueueRegisteredFormat(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given an importQueue with a custom format registered and a queue for its topic", t, func() {
+		registry := NewRegistry()
+		registry.Register("custom", customDispatcher)
+		customQueue := make(chan []byte, 2)
+		importer := CreateImportQueue(registry, map[string]chan []byte{"custom-topic": customQueue})
+
+		Convey("Then only the custom format is supported", func() {
+			So(importer.Formats(), ShouldResemble, []string{"custom"})
+		})
+
+		Convey("Then importing a job that fails the dispatcher validation returns its error", func() {
+			err := importer.Queue(ctx, &models.ImportData{JobID: "jobId", Format: "custom"})
+			So(errors.Is(err, errs.ErrJobNotImportable), ShouldBeTrue)
+			So(err.Error(), ShouldEndWith, ": uploaded files are required")
+			So(customQueue, ShouldBeEmpty)
+		})
+
+		Convey("Then validating a job checks it against the dispatcher of its format, without producing any event", func() {
+			So(errors.Is(importer.Validate(&models.ImportData{JobID: "jobId", Format: "custom"}), errs.ErrJobNotImportable), ShouldBeTrue)
+			So(importer.Validate(&models.ImportData{JobID: "jobId", Format: "other"}), ShouldEqual, errs.ErrUnsupportedFormat)
+			So(importer.Validate(&models.ImportData{
+				JobID:         "jobId",
+				Format:        "custom",
+				UploadedFiles: &[]models.UploadedFile{{AliasName: "file1", URL: "s3//aws/000/file1.csv"}},
+			}), ShouldBeNil)
+			So(customQueue, ShouldBeEmpty)
+		})
+
+		Convey("Then importing a valid job sends every event built by the dispatcher to the queue of its topic", func() {
+			err := importer.Queue(ctx, &models.ImportData{
+				JobID:  "jobId",
+				Format: "custom",
+				UploadedFiles: &[]models.UploadedFile{
+					{AliasName: "file1", URL: "s3//aws/000/file1.csv"},
+					{AliasName: "file2", URL: "s3//aws/000/file2.csv"},
+				},
+			})
+			So(err, ShouldBeNil)
+			So(customQueue, ShouldHaveLength, 2)
+
+			var event models.DataBakerEvent
+			So(schema.DataBaker.Unmarshal(<-customQueue, &event), ShouldBeNil)
+			So(event.JobID, ShouldEqual, "jobId/file1")
+			So(schema.DataBaker.Unmarshal(<-customQueue, &event), ShouldBeNil)
+			So(event.JobID, ShouldEqual, "jobId/file2")
+		})
+	})
+}
@@ -0,0 +1,204 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	"github.com/ONSdigital/dp-import-api/models"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// outboxBatchSize is the maximum number of pending outbox messages sent by each run of the relay
+const outboxBatchSize = 100
+
+// outboxMaxAttempts is the number of failed attempts to send an outbox message after which it is no longer retried
+const outboxMaxAttempts = 10
+
+// outboxClaimExpiry is how long an outbox message claimed by a process is left to it, after which the message
+// can be claimed by another process, e.g. because the process that claimed it stopped before sending it
+const outboxClaimExpiry = 5 * time.Minute
+
+// addOutboxMessage stores a new pending outbox message for the given jobID
+func (service Service) addOutboxMessage(ctx context.Context, jobID string) (*models.OutboxMessage, error) {
+	messageID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.OutboxMessage{
+		ID:    messageID.String(),
+		JobID: jobID,
+		State: models.OutboxPendingState,
+	}
+
+	if err = service.dataStore.AddOutboxMessage(ctx, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// discardOutboxMessage discards the provided outbox message, if any, when its job could not be submitted.
+// Failures are only logged, as the relay also discards the messages of jobs that are not submitted.
+func (service Service) discardOutboxMessage(ctx context.Context, message *models.OutboxMessage) {
+	if message == nil {
+		return
+	}
+
+	if err := service.dataStore.UpdateOutboxMessageState(ctx, message.ID, models.OutboxDiscardedState); err != nil {
+		log.Error(ctx, "failed to discard outbox message", err, log.Data{"outbox_message": message})
+	}
+}
+
+// sendOutboxMessage attempts to send the provided outbox message straight away.
+// Failures are only logged, as the message stays pending and is retried by the relay.
+func (service Service) sendOutboxMessage(ctx context.Context, message *models.OutboxMessage) {
+	if err := service.publishOutboxMessage(ctx, message); err != nil {
+		log.Error(ctx, "failed to send outbox message, it will be retried by the outbox relay", err, log.Data{"outbox_message": message})
+	}
+}
+
+// publishOutboxMessage claims the provided outbox message, so that it is only sent by one process, prepares and
+// queues the import events of its job, and marks the message as sent. If the job is not submitted, the message is
+// discarded instead, unless the job is still created and the message recent, as the job may be being submitted.
+// Failures to queue the events are recorded against the message, which is given up after outboxMaxAttempts.
+func (service Service) publishOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	claimed, err := service.dataStore.ClaimOutboxMessage(ctx, message.ID, time.Now().UTC().Add(-outboxClaimExpiry))
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		log.Info(ctx, "outbox message is claimed by another process or no longer pending, skipping it", log.Data{"outbox_message": message})
+		return nil
+	}
+
+	importJob, err := service.dataStore.GetJob(ctx, message.JobID)
+	if err != nil && !errors.Is(err, errs.ErrJobNotFound) {
+		return err
+	}
+
+	// the message of a job being submitted is stored before the job is, so it is left pending until the claim expiry
+	if err == nil && importJob.State == models.CreatedState && time.Since(message.CreatedAt) < outboxClaimExpiry {
+		log.Info(ctx, "job is not submitted yet, leaving outbox message pending", log.Data{"outbox_message": message})
+		return service.dataStore.UpdateOutboxMessageState(ctx, message.ID, models.OutboxPendingState)
+	}
+
+	if err != nil || importJob.State != models.SubmittedState {
+		log.Info(ctx, "job is not submitted, discarding outbox message", log.Data{"outbox_message": message})
+		return service.dataStore.UpdateOutboxMessageState(ctx, message.ID, models.OutboxDiscardedState)
+	}
+
+	if err = service.queueJob(ctx, importJob); err != nil {
+		service.recordOutboxMessageFailure(ctx, message, importJob)
+		return err
+	}
+
+	return service.dataStore.UpdateOutboxMessageState(ctx, message.ID, models.OutboxSentState)
+}
+
+// recordOutboxMessageFailure records a failed attempt to send the provided outbox message. Once the message has
+// failed outboxMaxAttempts times, it is marked as failed along with its job, so that it is no longer retried.
+// Failures are only logged, as the message stays pending and is retried by the relay.
+func (service Service) recordOutboxMessageFailure(ctx context.Context, message *models.OutboxMessage, importJob *models.Job) {
+	logData := log.Data{"outbox_message": message, "job_id": importJob.ID}
+
+	if err := service.dataStore.IncreaseOutboxMessageAttempts(ctx, message.ID); err != nil {
+		log.Error(ctx, "failed to record a failed attempt to send an outbox message", err, logData)
+		return
+	}
+	message.Attempts++
+
+	if message.Attempts < outboxMaxAttempts {
+		return
+	}
+
+	log.Warn(ctx, "outbox message has reached the maximum number of attempts, failing the job", logData)
+	if err := service.dataStore.UpdateOutboxMessageState(ctx, message.ID, models.OutboxFailedState); err != nil {
+		log.Error(ctx, "failed to mark outbox message as failed", err, logData)
+		return
+	}
+
+	// the job is only failed if it has not changed, e.g. been cancelled, since it was read
+	if err := service.dataStore.UpdateJob(ctx, importJob.ID, &models.Job{State: models.FailedState}, importJob.ETag()); err != nil {
+		log.Error(ctx, "failed to fail the job of an outbox message that could not be sent", err, logData)
+	}
+}
+
+// RelayOutbox sends the pending outbox messages created before the provided time, along with the messages whose
+// claim has expired. Messages that cannot be sent stay pending, to be retried by the next run.
+func (service Service) RelayOutbox(ctx context.Context, createdBefore time.Time) error {
+	messages, err := service.dataStore.GetPendingOutboxMessages(ctx, createdBefore, time.Now().UTC().Add(-outboxClaimExpiry), outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := service.publishOutboxMessage(ctx, message); err != nil {
+			log.Error(ctx, "failed to send outbox message", err, log.Data{"outbox_message": message})
+			continue
+		}
+		log.Info(ctx, "outbox message relayed", log.Data{"outbox_message": message})
+	}
+
+	return nil
+}
+
+// OutboxRelay periodically sends the outbox messages that are still pending
+type OutboxRelay struct {
+	service  *Service
+	interval time.Duration
+	closing  chan struct{}
+	closed   chan struct{}
+}
+
+// NewOutboxRelay returns a new OutboxRelay sending the pending outbox messages with the given interval
+func NewOutboxRelay(service *Service, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the relay in a new go-routine. Only the messages older than the interval are sent, so that the
+// messages of the jobs being submitted are left to the requests that created them.
+func (relay *OutboxRelay) Start(ctx context.Context) {
+	relay.closing = make(chan struct{})
+	relay.closed = make(chan struct{})
+
+	go func() {
+		defer close(relay.closed)
+
+		ticker := time.NewTicker(relay.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := relay.service.RelayOutbox(ctx, time.Now().UTC().Add(-relay.interval)); err != nil {
+					log.Error(ctx, "failed to relay outbox messages", err)
+				}
+			case <-relay.closing:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the relay, waiting for any run in progress to finish
+func (relay *OutboxRelay) Close(ctx context.Context) error {
+	if relay.closing == nil {
+		return nil
+	}
+
+	close(relay.closing)
+	select {
+	case <-relay.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
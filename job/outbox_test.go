@@ -0,0 +1,281 @@
+package job_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
+	"github.com/ONSdigital/dp-api-clients-go/v2/recipe"
+	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
+	"github.com/ONSdigital/dp-import-api/job"
+	"github.com/ONSdigital/dp-import-api/job/testjob"
+	"github.com/ONSdigital/dp-import-api/models"
+	. "github.com/smartystreets/goconvey/convey"
+	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestService_RelayOutbox(t *testing.T) {
+
+	Convey("Given a job service with a datastore containing pending outbox messages for a submitted, a cancelled and a missing job", t, func() {
+
+		jobs := map[string]*models.Job{
+			"submittedJob": {ID: "submittedJob", RecipeID: "123-234-456", State: models.SubmittedState},
+			"cancelledJob": {ID: "cancelledJob", RecipeID: "123-234-456", State: models.CancelledState},
+		}
+		messages := []*models.OutboxMessage{
+			{ID: "message1", JobID: "submittedJob", State: models.OutboxPendingState},
+			{ID: "message2", JobID: "cancelledJob", State: models.OutboxPendingState},
+			{ID: "message3", JobID: "missingJob", State: models.OutboxPendingState},
+		}
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+				return messages, nil
+			},
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				if importJob, ok := jobs[jobID]; ok {
+					return importJob, nil
+				}
+				return nil, errs.ErrJobNotFound
+			},
+			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+			IncreaseOutboxMessageAttemptsFunc: func(ctx context.Context, id string) error {
+				return nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
+			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
+				return nil
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+		createdBefore := time.Now().UTC()
+
+		Convey("When the outbox is relayed", func() {
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the pending messages created before the provided time are requested", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.GetPendingOutboxMessagesCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.GetPendingOutboxMessagesCalls()[0].CreatedBefore, ShouldEqual, createdBefore)
+				So(mockDataStore.GetPendingOutboxMessagesCalls()[0].ClaimedBefore, ShouldHappenBefore, time.Now().UTC().Add(-4*time.Minute))
+			})
+
+			Convey("Then each message is claimed before it is sent", func() {
+				calls := mockDataStore.ClaimOutboxMessageCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].ID, ShouldEqual, "message1")
+				So(calls[1].ID, ShouldEqual, "message2")
+				So(calls[2].ID, ShouldEqual, "message3")
+			})
+
+			Convey("Then only the submitted job is queued", func() {
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 1)
+				So(mockedQueue.QueueCalls()[0].Job.JobID, ShouldEqual, "submittedJob")
+			})
+
+			Convey("Then the message of the submitted job is sent, and the other messages are discarded", func() {
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].ID, ShouldEqual, "message1")
+				So(calls[0].State, ShouldEqual, models.OutboxSentState)
+				So(calls[1].ID, ShouldEqual, "message2")
+				So(calls[1].State, ShouldEqual, models.OutboxDiscardedState)
+				So(calls[2].ID, ShouldEqual, "message3")
+				So(calls[2].State, ShouldEqual, models.OutboxDiscardedState)
+			})
+		})
+
+		Convey("When the outbox is relayed and the job cannot be queued", func() {
+			mockedQueue.QueueFunc = func(ctx context.Context, job *models.ImportData) error {
+				return errors.New("kafka is down")
+			}
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message of the submitted job stays pending, and the other messages are still discarded", func() {
+				So(err, ShouldBeNil)
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].ID, ShouldEqual, "message2")
+				So(calls[1].ID, ShouldEqual, "message3")
+			})
+
+			Convey("Then the failed attempt is recorded against the message, and the job is left submitted", func() {
+				So(mockDataStore.IncreaseOutboxMessageAttemptsCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.IncreaseOutboxMessageAttemptsCalls()[0].ID, ShouldEqual, "message1")
+				So(messages[0].Attempts, ShouldEqual, 1)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the outbox is relayed and the job cannot be queued for the last allowed attempt", func() {
+			mockedQueue.QueueFunc = func(ctx context.Context, job *models.ImportData) error {
+				return errors.New("kafka is down")
+			}
+			messages[0].Attempts = 9
+			jobs["submittedJob"].UniqueTimestamp = bsonprim.Timestamp{T: 1650000000, I: 1}
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message is marked as failed, so that it is no longer retried", func() {
+				So(err, ShouldBeNil)
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].ID, ShouldEqual, "message1")
+				So(calls[0].State, ShouldEqual, models.OutboxFailedState)
+			})
+
+			Convey("Then the job is failed, on the condition that it has not changed since it was read", func() {
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].JobID, ShouldEqual, "submittedJob")
+				So(mockDataStore.UpdateJobCalls()[0].Update, ShouldResemble, &models.Job{State: models.FailedState})
+				So(mockDataStore.UpdateJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+		})
+
+		Convey("When the outbox is relayed and the job of a recent message is still created", func() {
+			jobs["submittedJob"].State = models.CreatedState
+			messages[0].CreatedAt = time.Now().UTC().Add(-time.Minute)
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message is left pending, as the job may be being submitted, and the job is not queued", func() {
+				So(err, ShouldBeNil)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].ID, ShouldEqual, "message1")
+				So(calls[0].State, ShouldEqual, models.OutboxPendingState)
+			})
+		})
+
+		Convey("When the outbox is relayed and the job of a message older than the claim expiry is still created", func() {
+			jobs["submittedJob"].State = models.CreatedState
+			messages[0].CreatedAt = time.Now().UTC().Add(-time.Hour)
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message is discarded, as the job was not submitted", func() {
+				So(err, ShouldBeNil)
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 3)
+				So(calls[0].ID, ShouldEqual, "message1")
+				So(calls[0].State, ShouldEqual, models.OutboxDiscardedState)
+			})
+		})
+
+		Convey("When the outbox is relayed and a message has been claimed by another process", func() {
+			mockDataStore.ClaimOutboxMessageFunc = func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return id != "message1", nil
+			}
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message is skipped, and the job is not queued", func() {
+				So(err, ShouldBeNil)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+				calls := mockDataStore.UpdateOutboxMessageStateCalls()
+				So(calls, ShouldHaveLength, 2)
+				So(calls[0].ID, ShouldEqual, "message2")
+				So(calls[1].ID, ShouldEqual, "message3")
+			})
+		})
+
+		Convey("When the outbox is relayed and a message cannot be claimed", func() {
+			mockDataStore.ClaimOutboxMessageFunc = func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				if id == "message1" {
+					return false, errors.New("mongo is down")
+				}
+				return true, nil
+			}
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the message is left for the next run, and the other messages are still relayed", func() {
+				So(err, ShouldBeNil)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+				So(mockDataStore.GetJobCalls(), ShouldHaveLength, 2)
+				So(mockDataStore.UpdateOutboxMessageStateCalls(), ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the pending messages cannot be retrieved", func() {
+			mockDataStore.GetPendingOutboxMessagesFunc = func(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+				return nil, errors.New("mongo is down")
+			}
+
+			err := jobService.RelayOutbox(ctx, createdBefore)
+
+			Convey("Then the error is returned and nothing is queued", func() {
+				So(err, ShouldNotBeNil)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestOutboxRelay(t *testing.T) {
+
+	Convey("Given an outbox relay with a short interval", t, func() {
+
+		relayed := make(chan time.Time, 1)
+		mockDataStore := &dsmock.DataStorerMock{
+			GetPendingOutboxMessagesFunc: func(ctx context.Context, createdBefore time.Time, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+				select {
+				case relayed <- createdBefore:
+				default:
+				}
+				return []*models.OutboxMessage{}, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+		relay := job.NewOutboxRelay(jobService, 10*time.Millisecond)
+
+		Convey("When the relay is started", func() {
+			startTime := time.Now().UTC()
+			relay.Start(ctx)
+
+			Convey("Then the pending messages older than the interval are relayed, until the relay is closed", func() {
+				createdBefore := <-relayed
+				So(createdBefore, ShouldHappenAfter, startTime.Add(-10*time.Millisecond))
+				So(createdBefore, ShouldHappenBefore, time.Now().UTC())
+				So(relay.Close(ctx), ShouldBeNil)
+			})
+		})
+
+		Convey("When the relay is closed without being started", func() {
+			err := relay.Close(ctx)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
@@ -3,6 +3,7 @@ package job
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	"github.com/ONSdigital/dp-api-clients-go/v2/headers"
@@ -12,6 +13,7 @@ import (
 	"github.com/ONSdigital/dp-import-api/datastore"
 	"github.com/ONSdigital/dp-import-api/models"
 	"github.com/ONSdigital/dp-import-api/url"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -46,6 +48,8 @@ type Service struct {
 // Queue interface used to queue import jobs.
 type Queue interface {
 	Queue(ctx context.Context, job *models.ImportData) error
+	Validate(job *models.ImportData) error
+	Formats() []string
 }
 
 // DatasetAPIClient interface to the dataset API.
@@ -77,6 +81,8 @@ func NewService(dataStore datastore.DataStorer, queue Queue, datasetAPIURL strin
 // CreateJob creates a new job using the instances corresponding to the recipe defined by recipeID in the provided job.
 // A new instance will be posted to dataset api for each outputInstance defined in the recipe.
 // Note that the provided job will be modified (ID, links and counts will be updated).
+// If another job has already been created with the idempotency key of the provided job,
+// its instances are rolled back and ErrDuplicateIdempotencyKey is returned.
 func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
 	logData := log.Data{"job": job}
 
@@ -87,10 +93,10 @@ func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.
 	}
 
 	// Get details needed for instances from Recipe API
-	jobRecipe, err := service.recipeAPIClient.GetRecipe(ctx, "", "", job.RecipeID)
+	jobRecipe, err := service.getSupportedRecipe(ctx, job.RecipeID)
 	if err != nil {
-		log.Error(ctx, "CreateJob: failed to get recipe details", err, logData)
-		return nil, ErrGetRecipeFailed
+		log.Error(ctx, "CreateJob: failed to get a supported recipe", err, logData)
+		return nil, err
 	}
 
 	// Generate a new random UUID
@@ -109,10 +115,100 @@ func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.
 		HRef: service.urlBuilder.GetJobURL(job.ID),
 		ID:   job.ID,
 	}
+	job.Attempt = 1
+
+	// Keep the recipe the instances are built from, so that the job is dispatched with it
+	job.RecipeSnapshot = models.NewRecipeSnapshot(jobRecipe)
+
+	if err = service.createInstances(ctx, job, job.RecipeSnapshot); err != nil {
+		return nil, err
+	}
+
+	// Add job to dataStore
+	createdJob, err := service.dataStore.AddJob(ctx, job)
+	if err != nil {
+		log.Error(ctx, "CreateJob: failed to create job in datastore", err, logData)
+		service.rollbackInstances(ctx, job)
+		if errors.Is(err, errs.ErrDuplicateIdempotencyKey) {
+			return nil, err
+		}
+		return nil, ErrSaveJobFailed
+	}
+
+	return createdJob, nil
+}
+
+// Formats returns the job formats that can be dispatched by this service
+func (service Service) Formats() []string {
+	return service.queue.Formats()
+}
+
+// getSupportedRecipe gets the recipe for the given recipeID from the recipe API,
+// and checks that its format can be dispatched by this service.
+func (service Service) getSupportedRecipe(ctx context.Context, recipeID string) (*recipe.Recipe, error) {
+	jobRecipe, err := service.recipeAPIClient.GetRecipe(ctx, "", "", recipeID)
+	if err != nil {
+		log.Error(ctx, "failed to get recipe details", err, log.Data{"recipe_id": recipeID})
+		return nil, ErrGetRecipeFailed
+	}
 
+	if !service.isSupportedFormat(jobRecipe.Format) {
+		return nil, errs.ErrUnsupportedFormat
+	}
+
+	return jobRecipe, nil
+}
+
+// getJobRecipe returns the snapshot of the recipe the provided job was created from. Jobs created before recipes were
+// snapshotted use the current recipe from the recipe API instead. The format of the recipe must be supported by this service,
+// and its input files must be mappable to its output instances.
+func (service Service) getJobRecipe(ctx context.Context, job *models.Job) (*models.RecipeSnapshot, error) {
+	jobRecipe := job.RecipeSnapshot
+	if jobRecipe == nil {
+		currentRecipe, err := service.getSupportedRecipe(ctx, job.RecipeID)
+		if err != nil {
+			return nil, err
+		}
+		jobRecipe = models.NewRecipeSnapshot(currentRecipe)
+	} else if !service.isSupportedFormat(jobRecipe.Format) {
+		return nil, errs.ErrUnsupportedFormat
+	}
+
+	if err := jobRecipe.ValidateMapping(); err != nil {
+		return nil, err
+	}
+
+	return jobRecipe, nil
+}
+
+// isSupportedFormat returns true if jobs of the provided format can be dispatched by this service
+func (service Service) isSupportedFormat(format string) bool {
+	for _, supported := range service.queue.Formats() {
+		if supported == format {
+			return true
+		}
+	}
+	return false
+}
+
+// createInstances posts a new instance to dataset api for each outputInstance defined in the provided recipe snapshot,
+// replacing the instance links, file mappings and processed counts of the provided job. Each instance is imported from
+// the uploaded file with the alias name given by the recipe snapshot. The job ID and self link must be set.
+// If the input files of the recipe cannot be mapped to its output instances, no instance is created.
+// If an instance cannot be created, the instances already created for the job are rolled back.
+func (service Service) createInstances(ctx context.Context, job *models.Job, jobRecipe *models.RecipeSnapshot) error {
+	job.Links.Instances = nil
+	job.InstanceFiles = []models.InstanceFile{}
 	job.Processed = []models.ProcessedInstances{}
 
-	for _, oi := range jobRecipe.OutputInstances {
+	for i, oi := range jobRecipe.OutputInstances {
+		aliasName, err := jobRecipe.InstanceAliasName(i)
+		if err != nil {
+			log.Error(ctx, "createInstances: recipe cannot be mapped to instances", err, log.Data{"job_id": job.ID, "recipe_id": jobRecipe.ID})
+			service.rollbackInstances(ctx, job)
+			return err
+		}
+
 		// Create a new instance by sending a 'POST /instances' to dataset API
 		datasetPath := service.datasetAPIURL + "/datasets/" + oi.DatasetID
 		newInstance := models.CreateInstance(job, oi.DatasetID, datasetPath, oi.CodeLists)
@@ -120,8 +216,9 @@ func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.
 		newInstance.LowestGeography = oi.LowestGeography
 		instance, _, err := service.datasetAPIClient.PostInstance(ctx, service.serviceAuthToken, newInstance)
 		if err != nil {
-			log.Error(ctx, "CreateJob: failed to create instance in datastore", err, log.Data{"job_id": job.ID, "job_url": job.Links.Self.HRef, "instance": oi})
-			return nil, ErrCreateInstanceFailed(oi.DatasetID)
+			log.Error(ctx, "createInstances: failed to create instance in datastore", err, log.Data{"job_id": job.ID, "job_url": job.Links.Self.HRef, "instance": oi})
+			service.rollbackInstances(ctx, job)
+			return ErrCreateInstanceFailed(oi.DatasetID)
 		}
 
 		// Append the new instance link to provided job
@@ -132,6 +229,15 @@ func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.
 			},
 		)
 
+		// Map the current instance to its dataset and to the file it is imported from
+		job.InstanceFiles = append(job.InstanceFiles,
+			models.InstanceFile{
+				InstanceID: instance.ID,
+				DatasetID:  oi.DatasetID,
+				AliasName:  aliasName,
+			},
+		)
+
 		// Initialise the processed instances count for the current instance
 		job.Processed = append(job.Processed,
 			models.ProcessedInstances{
@@ -142,63 +248,418 @@ func (service Service) CreateJob(ctx context.Context, job *models.Job) (*models.
 		)
 	}
 
-	// Add job to dataStore
-	createdJob, err := service.dataStore.AddJob(ctx, job)
+	return nil
+}
+
+// UpdateJob updates the job for the given jobID with the values in the given job model.
+// If the state is changed, the transition from the current state of the stored job must be allowed.
+// Submitted jobs are recorded in the outbox, so that their import events are sent by the outbox relay
+// if they cannot be sent straight away. Unless any eTag is allowed, the job is only updated if its eTag matches the provided one.
+// Only the fields clients are allowed to update can be set in the given job model.
+func (service Service) UpdateJob(ctx context.Context, jobID string, job *models.Job, eTag string) error {
+
+	if err := job.ValidateUpdate(); err != nil {
+		log.Error(ctx, "UpdateJob: read-only fields cannot be updated", err, log.Data{"job_id": jobID, "read_only_fields": job.ReadOnlyFields()})
+		return err
+	}
+
+	currentJob, err := service.getJobMatchingETag(ctx, jobID, eTag)
 	if err != nil {
-		log.Error(ctx, "CreateJob: failed to create job in datastore", err, logData)
-		return nil, ErrSaveJobFailed
+		return err
 	}
 
-	return createdJob, nil
+	return service.applyUpdate(ctx, jobID, currentJob, job, eTag)
 }
 
-// UpdateJob updates the job for the given jobID with the values in the given job model.
-func (service Service) UpdateJob(ctx context.Context, jobID string, job *models.Job) error {
+// PatchJob applies the given patch operations to the job for the given jobID, following the same rules as UpdateJob.
+// Only the fields changed by the patches are updated, and only if the job has not been modified since the
+// patches were applied to it, so that the patch is applied atomically.
+func (service Service) PatchJob(ctx context.Context, jobID string, patches []dprequest.Patch, eTag string) error {
+
+	currentJob, err := service.getJobMatchingETag(ctx, jobID, eTag)
+	if err != nil {
+		return err
+	}
+
+	update, err := currentJob.ApplyPatches(patches)
+	if err != nil {
+		log.Error(ctx, "PatchJob: failed to apply patches", err, log.Data{"job_id": jobID, "patches": patches})
+		return err
+	}
+
+	if err = update.ValidateState(); err != nil {
+		log.Error(ctx, "PatchJob: invalid state", err, log.Data{"job_id": jobID, "state": update.State})
+		return err
+	}
+
+	return service.applyUpdate(ctx, jobID, currentJob, update, currentJob.ETag())
+}
+
+// GetJobReadiness checks whether the uploaded files of the job for the given jobID match the input files expected by its recipe.
+// If they do, the job must also be importable, e.g. the instances of a v4 job must be mapped to uploaded files, otherwise
+// an error is returned rather than reporting a job that could never be submitted as ready.
+func (service Service) GetJobReadiness(ctx context.Context, jobID string) (*models.Readiness, error) {
+
+	currentJob, err := service.dataStore.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobRecipe, err := service.getJobRecipe(ctx, currentJob)
+	if err != nil {
+		return nil, err
+	}
+
+	readiness := currentJob.CheckReadiness(jobRecipe)
+	if !readiness.Ready {
+		return readiness, nil
+	}
+
+	if err = service.queue.Validate(newImportData(currentJob, jobRecipe.Format)); err != nil {
+		log.Error(ctx, "job cannot be imported", err, log.Data{"job_id": jobID})
+		return nil, err
+	}
 
-	err := service.dataStore.UpdateJob(ctx, jobID, job)
+	return readiness, nil
+}
+
+// getJobMatchingETag gets the job for the given jobID, failing if its eTag does not match the provided one, unless any eTag is allowed
+func (service Service) getJobMatchingETag(ctx context.Context, jobID, eTag string) (*models.Job, error) {
+
+	currentJob, err := service.dataStore.GetJob(ctx, jobID)
 	if err != nil {
+		return nil, err
+	}
+
+	if eTag != "" && eTag != models.AnyETag && eTag != currentJob.ETag() {
+		log.Error(ctx, "eTag does not match", errs.ErrJobETagMismatch, log.Data{"job_id": jobID, "current_etag": currentJob.ETag(), "etag": eTag})
+		return nil, errs.ErrJobETagMismatch
+	}
+
+	return currentJob, nil
+}
+
+// applyUpdate stores the given update of the current job, on the condition that the job eTag matches the provided one.
+// A job is only submitted if it has not changed since the current job was read, whatever the provided eTag.
+// If the state is changed, the transition from the current state must be allowed, and submitted or cancelled jobs are handled.
+// Setting the state of a completed or failed job to the state it is already in is accepted, and nothing is changed.
+func (service Service) applyUpdate(ctx context.Context, jobID string, currentJob, job *models.Job, eTag string) (err error) {
+	if job.IsRepeatedTerminalState(currentJob.State) {
+		log.Info(ctx, "job is already in the requested state, nothing to update", log.Data{"job_id": jobID, "state": job.State})
+		return nil
+	}
+
+	if err = job.ValidateTransition(currentJob.State); err != nil {
+		log.Error(ctx, "invalid state transition", err, log.Data{"job_id": jobID, "current_state": currentJob.State, "state": job.State})
+		return err
+	}
+
+	var message *models.OutboxMessage
+	if job.State == models.SubmittedState {
+		var jobRecipe *models.RecipeSnapshot
+		if jobRecipe, err = service.getJobRecipe(ctx, currentJob); err != nil {
+			log.Error(ctx, "failed to get a supported recipe", err, log.Data{"job_id": jobID, "recipe_id": currentJob.RecipeID})
+			return err
+		}
+
+		// the files being updated are the ones that will be submitted
+		submittedJob := *currentJob
+		if job.UploadedFiles != nil {
+			submittedJob.UploadedFiles = job.UploadedFiles
+		}
+		if err = service.validateSubmission(ctx, &submittedJob, jobRecipe); err != nil {
+			return err
+		}
+
+		// the job is only submitted if it has not changed since it was checked, e.g. by a file being removed
+		eTag = currentJob.ETag()
+
+		// the message is stored before the job is submitted, so that its events are sent even if this process stops
+		if message, err = service.addOutboxMessage(ctx, jobID); err != nil {
+			log.Error(ctx, "failed to add outbox message", err, log.Data{"job_id": jobID})
+			return err
+		}
+	}
+
+	err = service.dataStore.UpdateJob(ctx, jobID, job, eTag)
+	if err != nil {
+		service.discardOutboxMessage(ctx, message)
 		return err
 	}
 
 	log.Info(ctx, "job updated", log.Data{"job": job, "job_id": jobID})
-	if job.State == "submitted" {
-		tasks, err := service.prepareJob(ctx, jobID)
-		if err != nil {
-			log.Error(ctx, "error preparing job", err, log.Data{"jobState": job, "job_id": jobID})
+	if job.State == models.CancelledState {
+		if err = service.failInstances(ctx, currentJob); err != nil {
+			log.Error(ctx, "error failing the instances of a cancelled job", err, log.Data{"job_id": jobID})
 			return err
 		}
 
-		err = service.queue.Queue(ctx, tasks)
-		if err != nil {
-			log.Error(ctx, "error queueing tasks", err, log.Data{"tasks": tasks})
+		log.Info(ctx, "import job was cancelled", log.Data{"job_id": jobID})
+	}
+
+	if message != nil {
+		service.sendOutboxMessage(ctx, message)
+	}
+
+	return nil
+}
+
+// validateSubmission checks that the provided job, as it is submitted, can be imported with the provided recipe: its files
+// must match the input files of the recipe, and its import events must be valid, as they could never be queued otherwise.
+func (service Service) validateSubmission(ctx context.Context, submittedJob *models.Job, jobRecipe *models.RecipeSnapshot) error {
+	readiness := submittedJob.CheckReadiness(jobRecipe)
+	if err := readiness.ValidateReadiness(); err != nil {
+		log.Error(ctx, "job is not ready to be submitted", err, log.Data{"job_id": submittedJob.ID, "readiness": readiness})
+		return err
+	}
+
+	if err := service.queue.Validate(newImportData(submittedJob, jobRecipe.Format)); err != nil {
+		log.Error(ctx, "job cannot be imported", err, log.Data{"job_id": submittedJob.ID})
+		return err
+	}
+
+	return nil
+}
+
+// RetryJob starts a new attempt of the failed job for the given jobID. The processed counts are reset and,
+// if requested, a new set of instances is created from the recipe, before the job is submitted again.
+func (service Service) RetryJob(ctx context.Context, jobID string, options *models.RetryOptions) error {
+	logData := log.Data{"job_id": jobID, "options": options}
+
+	currentJob, err := service.dataStore.GetJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	if currentJob.State != models.FailedState {
+		log.Error(ctx, "RetryJob: job is not failed", errs.ErrJobNotFailed, log.Data{"job_id": jobID, "current_state": currentJob.State})
+		return errs.ErrJobNotFailed
+	}
+
+	// jobs created before attempts were recorded are on their first attempt
+	attempt := currentJob.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	retry := &models.Job{
+		State:   models.SubmittedState,
+		Attempt: attempt + 1,
+	}
+
+	// jobs created before recipes were snapshotted keep the recipe they are retried with
+	jobRecipe, err := service.getJobRecipe(ctx, currentJob)
+	if err != nil {
+		log.Error(ctx, "RetryJob: failed to get a supported recipe", err, logData)
+		return err
+	}
+	retry.RecipeSnapshot = jobRecipe
+
+	// the files of a failed job cannot be changed, so a job that cannot be submitted again could never be retried
+	retriedJob, err := plannedRetry(currentJob, jobRecipe, options)
+	if err == nil {
+		err = service.validateSubmission(ctx, retriedJob, jobRecipe)
+	}
+	if err != nil {
+		log.Error(ctx, "RetryJob: job cannot be submitted again", err, logData)
+		return errs.ErrorJobNotRetriable(err)
+	}
+
+	if options.RecreateInstances {
+		retry.ID = jobID
+		retry.Links = &models.LinksMap{
+			Self: models.IDLink{
+				HRef: service.urlBuilder.GetJobURL(jobID),
+				ID:   jobID,
+			},
+		}
+		if err = service.createInstances(ctx, retry, jobRecipe); err != nil {
 			return err
 		}
+	} else {
+		retry.Processed = []models.ProcessedInstances{}
+		for _, processed := range currentJob.Processed {
+			retry.Processed = append(retry.Processed, models.ProcessedInstances{
+				ID:            processed.ID,
+				RequiredCount: processed.RequiredCount,
+			})
+		}
+	}
 
-		log.Info(ctx, "import job was queued", log.Data{"job": job, "job_id": jobID})
+	message, err := service.addOutboxMessage(ctx, jobID)
+	if err != nil {
+		log.Error(ctx, "RetryJob: failed to add outbox message", err, logData)
+		if options.RecreateInstances {
+			service.rollbackInstances(ctx, retry)
+		}
+		return err
 	}
 
+	if err = service.dataStore.RetryJob(ctx, jobID, retry); err != nil {
+		service.discardOutboxMessage(ctx, message)
+		if options.RecreateInstances {
+			service.rollbackInstances(ctx, retry)
+		}
+		return err
+	}
+
+	log.Info(ctx, "job reset for a new attempt", log.Data{"job_id": jobID, "attempt": retry.Attempt})
+
+	// the instances of the previous attempt have been replaced, so they are no longer imported by the job
+	if options.RecreateInstances {
+		if err = service.failInstances(ctx, currentJob); err != nil {
+			log.Error(ctx, "RetryJob: error failing the instances of the previous attempt", err, logData)
+		}
+	}
+
+	service.sendOutboxMessage(ctx, message)
 	return nil
 }
 
-// PrepareJob returns a format ready to send to downstream services via kafka
-func (service Service) prepareJob(ctx context.Context, jobID string) (*models.ImportData, error) {
+// plannedRetry returns a copy of the provided failed job as it is submitted again with the provided options. When the
+// instances are recreated, the job has the instances planned by the recipe, identified by their dataset ID as they
+// have not been created yet.
+func plannedRetry(currentJob *models.Job, jobRecipe *models.RecipeSnapshot, options *models.RetryOptions) (*models.Job, error) {
+	retriedJob := *currentJob
+	if !options.RecreateInstances {
+		return &retriedJob, nil
+	}
 
-	importJob, err := service.dataStore.GetJob(ctx, jobID)
+	retriedJob.Links = &models.LinksMap{}
+	retriedJob.InstanceFiles = []models.InstanceFile{}
+	for i, oi := range jobRecipe.OutputInstances {
+		aliasName, err := jobRecipe.InstanceAliasName(i)
+		if err != nil {
+			return nil, err
+		}
+		retriedJob.Links.Instances = append(retriedJob.Links.Instances, models.IDLink{ID: oi.DatasetID})
+		retriedJob.InstanceFiles = append(retriedJob.InstanceFiles, models.InstanceFile{
+			InstanceID: oi.DatasetID,
+			DatasetID:  oi.DatasetID,
+			AliasName:  aliasName,
+		})
+	}
+	return &retriedJob, nil
+}
+
+// queueJob prepares the provided submitted job and queues it to be imported
+func (service Service) queueJob(ctx context.Context, importJob *models.Job) error {
+	tasks, err := service.prepareJob(ctx, importJob)
+	if err != nil {
+		log.Error(ctx, "error preparing job", err, log.Data{"job_id": importJob.ID})
+		return err
+	}
+
+	err = service.queue.Queue(ctx, tasks)
+	if err != nil {
+		log.Error(ctx, "error queueing tasks", err, log.Data{"tasks": tasks})
+		return err
+	}
+
+	log.Info(ctx, "import job was queued", log.Data{"job_id": importJob.ID})
+	return nil
+}
+
+// CancelJob cancels the job for the given jobID, and moves each of its instances to the failed state in the dataset API.
+func (service Service) CancelJob(ctx context.Context, jobID string) error {
+	return service.UpdateJob(ctx, jobID, &models.Job{State: models.CancelledState}, models.AnyETag)
+}
+
+// failInstances moves each instance linked to the provided job to the failed state in the dataset API.
+// Every instance is attempted, and the first error encountered is returned.
+func (service Service) failInstances(ctx context.Context, job *models.Job) (err error) {
+	if job.Links == nil {
+		return nil
+	}
+
+	for _, instanceRef := range job.Links.Instances {
+		_, putErr := service.datasetAPIClient.PutInstance(ctx, "", service.serviceAuthToken, "", instanceRef.ID,
+			dataset.UpdateInstance{
+				State: dataset.StateFailed.String(),
+			},
+			headers.IfMatchAnyETag,
+		)
+		if putErr != nil {
+			log.Error(ctx, "failed to move instance to the failed state", putErr, log.Data{"job_id": job.ID, "instance_id": instanceRef.ID})
+			if err == nil {
+				err = putErr
+			}
+		}
+	}
+
+	return err
+}
+
+// rollbackInstances moves the instances created for a job that could not be stored to the failed state in the
+// dataset API, so that they are not left dangling. Failures are only logged, as the caller returns the original error.
+func (service Service) rollbackInstances(ctx context.Context, job *models.Job) {
+	if job.Links == nil || len(job.Links.Instances) == 0 {
+		return
+	}
+
+	if err := service.failInstances(ctx, job); err != nil {
+		log.Error(ctx, "failed to roll back the instances created for the job", err, log.Data{"job_id": job.ID, "instances": job.Links.Instances})
+		return
+	}
+
+	log.Info(ctx, "instances created for the job have been rolled back", log.Data{"job_id": job.ID, "instances": job.Links.Instances})
+}
+
+// IncreaseProcessedInstance increases the processed count for the provided instance of a job, and returns the updated
+// processed instances. If a codelist ID or dimension name is provided, it is recorded against the instance and
+// the count is only increased the first time it is reported, so that retried calls are no-ops.
+// If every instance of a submitted job has then reached its required count, the job is completed.
+// Both updates are atomic in the datastore, so that concurrent calls are safe.
+// Unless any eTag is allowed, the count is only increased if the eTag of the job matches the provided one.
+func (service Service) IncreaseProcessedInstance(ctx context.Context, jobID, instanceID, dimension, eTag string) ([]models.ProcessedInstances, error) {
+	logData := log.Data{"job_id": jobID, "instance_id": instanceID, "dimension": dimension}
+
+	processed, err := service.dataStore.IncreaseProcessedInstance(ctx, jobID, instanceID, dimension, eTag)
 	if err != nil {
 		return nil, err
 	}
 
-	jobRecipe, err := service.recipeAPIClient.GetRecipe(ctx, "", "", importJob.RecipeID)
+	if models.IsProcessed(processed) {
+		if err := service.completeJob(ctx, jobID); err != nil {
+			log.Error(ctx, "IncreaseProcessedInstance: failed to complete job", err, logData)
+			return nil, err
+		}
+	}
+
+	return processed, nil
+}
+
+// completeJob moves a job to the completed state, stamping its completion time
+func (service Service) completeJob(ctx context.Context, jobID string) error {
+	completedAt := time.Now().UTC()
+	err := service.dataStore.UpdateJob(ctx, jobID, &models.Job{
+		State:       models.CompletedState,
+		CompletedAt: &completedAt,
+	}, models.AnyETag)
+	if errors.Is(err, errs.ErrInvalidStateTransition) {
+		// the job is not submitted, or it has already been completed or failed by a different caller
+		log.Warn(ctx, "job is not submitted and has not been completed", log.Data{"job_id": jobID})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info(ctx, "all instances have been processed, job completed", log.Data{"job_id": jobID})
+	return nil
+}
+
+// PrepareJob returns a format ready to send to downstream services via kafka
+func (service Service) prepareJob(ctx context.Context, importJob *models.Job) (*models.ImportData, error) {
+
+	jobRecipe, err := service.getJobRecipe(ctx, importJob)
 	if err != nil {
 		return nil, err
 	}
 
-	var instanceIds []string
 	if importJob.Links != nil {
 		for _, instanceRef := range importJob.Links.Instances {
-			instanceIds = append(instanceIds, instanceRef.ID)
-
-			_, err = service.datasetAPIClient.PutInstance(ctx, "", service.serviceAuthToken, "", instanceRef.ID,
+			_, err := service.datasetAPIClient.PutInstance(ctx, "", service.serviceAuthToken, "", instanceRef.ID,
 				dataset.UpdateInstance{
 					State: dataset.StateSubmitted.String(),
 				},
@@ -210,11 +671,24 @@ func (service Service) prepareJob(ctx context.Context, jobID string) (*models.Im
 		}
 	}
 
+	return newImportData(importJob, jobRecipe.Format), nil
+}
+
+// newImportData returns the data sent to downstream services to import the provided job with the given format
+func newImportData(importJob *models.Job, format string) *models.ImportData {
+	var instanceIds []string
+	if importJob.Links != nil {
+		for _, instanceRef := range importJob.Links.Instances {
+			instanceIds = append(instanceIds, instanceRef.ID)
+		}
+	}
+
 	return &models.ImportData{
-		JobID:         jobID,
+		JobID:         importJob.ID,
 		Recipe:        importJob.RecipeID,
-		Format:        jobRecipe.Format,
+		Format:        format,
 		UploadedFiles: importJob.UploadedFiles,
 		InstanceIDs:   instanceIds,
-	}, nil
+		InstanceFiles: importJob.InstanceFiles,
+	}
 }
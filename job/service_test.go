@@ -2,18 +2,25 @@ package job_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	"github.com/ONSdigital/dp-api-clients-go/v2/recipe"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
 	"github.com/ONSdigital/dp-import-api/job"
 	"github.com/ONSdigital/dp-import-api/job/testjob"
 	"github.com/ONSdigital/dp-import-api/models"
 	mongo "github.com/ONSdigital/dp-import-api/mongo/testmongo"
 	"github.com/ONSdigital/dp-import-api/url"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	. "github.com/smartystreets/goconvey/convey"
+	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const testETag = "testETag"
@@ -41,6 +48,16 @@ var (
 	ctx              = context.Background()
 )
 
+// supportedFormats returns the formats supported by the mocked queue, including the format of dummyRecipe
+func supportedFormats() []string {
+	return []string{"cantabular_blob", "v4"}
+}
+
+// validJob is the validation of a mocked queue that accepts every job
+func validJob(job *models.ImportData) error {
+	return nil
+}
+
 // dummyInstance generates a dataset Instance for testing
 func dummyInstance() *dataset.Instance {
 	return &dataset.Instance{
@@ -87,7 +104,7 @@ func TestService_CreateJob(t *testing.T) {
 	Convey("Given a job service with mocked dependencies", t, func() {
 
 		mockDataStore := &mongo.DataStorer{}
-		mockedQueue := &testjob.QueueMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
 		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
 			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
 				retInstance := dummyInstance()
@@ -121,6 +138,7 @@ func TestService_CreateJob(t *testing.T) {
 
 			Convey("Then the provided job is mutated with the expected ID and link values", func() {
 				So(jobModel.ID, ShouldNotBeBlank)
+				So(jobModel.Attempt, ShouldEqual, 1)
 				So(jobModel.Links, ShouldResemble, &models.LinksMap{
 					Self: models.IDLink{
 						ID:   jobModel.ID,
@@ -139,6 +157,19 @@ func TestService_CreateJob(t *testing.T) {
 				})
 			})
 
+			Convey("Then the recipe the instances are built from is stored with the job", func() {
+				So(jobModel.RecipeSnapshot, ShouldResemble, models.NewRecipeSnapshot(dummyRecipe))
+				So(jobModel.RecipeSnapshot.Format, ShouldEqual, "cantabular_blob")
+				So(jobModel.RecipeSnapshot.OutputInstances, ShouldHaveLength, 2)
+			})
+
+			Convey("Then each instance is mapped to its dataset, and to no file as the recipe does not declare any input files", func() {
+				So(jobModel.InstanceFiles, ShouldResemble, []models.InstanceFile{
+					{InstanceID: "dummyInstance_dataset1", DatasetID: "dataset1"},
+					{InstanceID: "dummyInstance_dataset2", DatasetID: "dataset2"},
+				})
+			})
+
 			Convey("Then the expected instances, as defined by the recipe, are posted to dataset API with the correct authentication", func() {
 				So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 2)
 				So(mockedDatasetAPI.PostInstanceCalls()[0].NewInstance, ShouldResemble, expectedNewInstance(jobModel.ID, "dataset1"))
@@ -155,7 +186,7 @@ func TestService_CreateJob_CreateInstanceFails(t *testing.T) {
 	Convey("Given a job service with a mock dataset API that returns a failure", t, func() {
 
 		mockDataStore := &mongo.DataStorer{}
-		mockedQueue := &testjob.QueueMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
 		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
 			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
 				return nil, "", errors.New("Create instance failed.")
@@ -181,6 +212,86 @@ func TestService_CreateJob_CreateInstanceFails(t *testing.T) {
 				So(err, ShouldResemble, job.ErrCreateInstanceFailed("dataset1"))
 				So(createdJob, ShouldBeNil)
 			})
+
+			Convey("Then there are no instances to roll back", func() {
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a job service with a mock dataset API that fails to create the second instance", t, func() {
+
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
+				if newInstance.Links.Dataset.ID == "dataset2" {
+					return nil, "", errors.New("Create instance failed.")
+				}
+				retInstance := dummyInstance()
+				retInstance.ID = "dummyInstance_" + newInstance.Links.Dataset.ID
+				return retInstance, testETag, nil
+			},
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+		mockDataStore := &dsmock.DataStorerMock{}
+
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When create job is called", func() {
+
+			createdJob, err := jobService.CreateJob(ctx, &models.Job{RecipeID: "123-234-456"})
+
+			Convey("The expected error is returned and the job is not stored", func() {
+				So(err, ShouldResemble, job.ErrCreateInstanceFailed("dataset2"))
+				So(createdJob, ShouldBeNil)
+				So(mockDataStore.AddJobCalls(), ShouldHaveLength, 0)
+			})
+
+			Convey("Then the instance that was created is moved to the failed state in dataset API", func() {
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 1)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].InstanceID, ShouldEqual, "dummyInstance_dataset1")
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateFailed.String())
+				So(mockedDatasetAPI.PutInstanceCalls()[0].ServiceAuthToken, ShouldEqual, serviceAuthToken)
+			})
+		})
+	})
+}
+
+func TestService_CreateJob_UnmappableRecipe(t *testing.T) {
+
+	Convey("Given a job service with a recipe declaring more input files than output instances, but not one per instance", t, func() {
+
+		var unmappableRecipe recipe.Recipe
+		b := []byte(`{"id":"123","format":"v4","files":[{"description":"CPIH v4"},{"description":"CPI v4"}],
+			"output_instances":[{"dataset_id":"cpih01"},{"dataset_id":"cpi01"},{"dataset_id":"cpi02"}]}`)
+		So(json.Unmarshal(b, &unmappableRecipe), ShouldBeNil)
+
+		mockDataStore := &dsmock.DataStorerMock{}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return &unmappableRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When create job is called", func() {
+
+			createdJob, err := jobService.CreateJob(ctx, &models.Job{RecipeID: "123"})
+
+			Convey("Then the recipe cannot be mapped, and no instance or job is created", func() {
+				So(errors.Is(err, errs.ErrUnmappableRecipe), ShouldBeTrue)
+				So(createdJob, ShouldBeNil)
+				So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 0)
+				So(mockDataStore.AddJobCalls(), ShouldHaveLength, 0)
+			})
 		})
 	})
 }
@@ -190,10 +301,15 @@ func TestService_CreateJob_SaveJobFails(t *testing.T) {
 	Convey("Given a job service with mocked dependencies", t, func() {
 
 		mockDataStore := &mongo.DataStorer{InternalError: true}
-		mockedQueue := &testjob.QueueMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
 		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
 			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
-				return dummyInstance(), testETag, nil
+				retInstance := dummyInstance()
+				retInstance.ID = "dummyInstance_" + newInstance.Links.Dataset.ID
+				return retInstance, testETag, nil
+			},
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
 			},
 		}
 		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
@@ -216,6 +332,65 @@ func TestService_CreateJob_SaveJobFails(t *testing.T) {
 				So(err, ShouldEqual, job.ErrSaveJobFailed)
 				So(createdJob, ShouldBeNil)
 			})
+
+			Convey("Then the instances created for the job are moved to the failed state in dataset API", func() {
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 2)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].InstanceID, ShouldEqual, "dummyInstance_dataset1")
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateFailed.String())
+				So(mockedDatasetAPI.PutInstanceCalls()[1].InstanceID, ShouldEqual, "dummyInstance_dataset2")
+				So(mockedDatasetAPI.PutInstanceCalls()[1].Instance.State, ShouldEqual, dataset.StateFailed.String())
+			})
+		})
+	})
+}
+
+func TestService_CreateJob_DuplicateIdempotencyKey(t *testing.T) {
+
+	Convey("Given a job service with a datastore that already has a job with the same idempotency key", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			AddJobFunc: func(ctx context.Context, importJob *models.Job) (*models.Job, error) {
+				return nil, errs.ErrDuplicateIdempotencyKey
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
+				retInstance := dummyInstance()
+				retInstance.ID = "dummyInstance_" + newInstance.Links.Dataset.ID
+				return retInstance, testETag, nil
+			},
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		newJob := &models.Job{
+			RecipeID:       "123-234-456",
+			IdempotencyKey: "key-123",
+		}
+
+		Convey("When create job is called", func() {
+
+			createdJob, err := jobService.CreateJob(ctx, newJob)
+
+			Convey("Then the duplicate idempotency key error is returned", func() {
+				So(err, ShouldEqual, errs.ErrDuplicateIdempotencyKey)
+				So(createdJob, ShouldBeNil)
+			})
+
+			Convey("Then the instances created for the job are moved to the failed state in dataset API", func() {
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 2)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateFailed.String())
+				So(mockedDatasetAPI.PutInstanceCalls()[1].Instance.State, ShouldEqual, dataset.StateFailed.String())
+			})
 		})
 	})
 }
@@ -225,7 +400,7 @@ func TestService_CreateJob_InvalidJob(t *testing.T) {
 	Convey("Given a job service with mocked dependencies", t, func() {
 
 		mockDataStore := &mongo.DataStorer{}
-		mockedQueue := &testjob.QueueMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
 		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
 		mockedRecipeAPI := &testjob.RecipeAPIClientMock{}
 
@@ -250,7 +425,7 @@ func TestService_CreateJob_GetRecipeFails(t *testing.T) {
 	Convey("Given a job service with a mock recipe API that returns an error", t, func() {
 
 		mockDataStore := &mongo.DataStorer{}
-		mockedQueue := &testjob.QueueMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
 		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
 		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
 			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
@@ -276,12 +451,63 @@ func TestService_CreateJob_GetRecipeFails(t *testing.T) {
 	})
 }
 
+func TestService_CreateJob_UnsupportedFormat(t *testing.T) {
+
+	Convey("Given a job service with a queue that does not support the format of the recipe", t, func() {
+
+		mockDataStore := &mongo.DataStorer{}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc: func() []string {
+				return []string{"v4"}
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When create job is called", func() {
+
+			createdJob, err := jobService.CreateJob(ctx, &models.Job{RecipeID: "123-234-456"})
+
+			Convey("Then an unsupported format error is returned and no instances are created", func() {
+				So(err, ShouldEqual, errs.ErrUnsupportedFormat)
+				So(createdJob, ShouldBeNil)
+				So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestService_Formats(t *testing.T) {
+
+	Convey("Given a job service with mocked dependencies", t, func() {
+
+		jobService := job.NewService(&mongo.DataStorer{}, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When formats is called", func() {
+
+			formats := jobService.Formats()
+
+			Convey("Then the formats supported by the queue are returned", func() {
+				So(formats, ShouldResemble, []string{"cantabular_blob", "v4"})
+			})
+		})
+	})
+}
+
 func TestService_UpdateJob(t *testing.T) {
 
 	Convey("Given a job service with mocked dependencies", t, func() {
 
 		mockDataStore := &mongo.DataStorer{}
 		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
 			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
 				return nil
 			},
@@ -296,14 +522,11 @@ func TestService_UpdateJob(t *testing.T) {
 		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
 
 		jobID := "123"
-		jobUpdate := &models.Job{
-			RecipeID: "123-234-456",
-			ID:       jobID,
-		}
+		jobUpdate := &models.Job{}
 
 		Convey("When update job is called", func() {
 
-			err := jobService.UpdateJob(ctx, jobID, jobUpdate)
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
 
 			Convey("The expected calls are made to dependencies", func() {
 				So(err, ShouldBeNil)
@@ -313,12 +536,95 @@ func TestService_UpdateJob(t *testing.T) {
 	})
 }
 
+func TestService_UpdateJob_ETag(t *testing.T) {
+
+	Convey("Given a job service with a stored job", t, func() {
+
+		storedJob := &models.Job{ID: "123", State: models.CreatedState, UniqueTimestamp: bsonprim.Timestamp{T: 1650000000, I: 1}}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When update job is called with the eTag of the stored job", func() {
+
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.CreatedState}, "1650000000-1")
+
+			Convey("Then the job is updated on the condition that its eTag has not changed", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+		})
+
+		Convey("When update job is called with a different eTag", func() {
+
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.CreatedState}, "1650000000-0")
+
+			Convey("Then the eTag mismatch error is returned and the job is not updated", func() {
+				So(err, ShouldEqual, errs.ErrJobETagMismatch)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestService_UpdateJob_ReadOnlyFields(t *testing.T) {
+
+	Convey("Given a job service with mocked dependencies", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When update job is called with fields managed by the import API", func() {
+
+			update := &models.Job{
+				ID:            "456",
+				State:         models.SubmittedState,
+				UploadedFiles: &[]models.UploadedFile{{AliasName: "v4", URL: "s3://bucket/v4.csv"}},
+			}
+			err := jobService.UpdateJob(ctx, "123", update, models.AnyETag)
+
+			Convey("Then the read-only fields are rejected and the datastore is not called", func() {
+				So(errors.Is(err, errs.ErrReadOnlyJobFields), ShouldBeTrue)
+				So(err.Error(), ShouldEndWith, ": id, files")
+				So(mockDataStore.GetJobCalls(), ShouldBeEmpty)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When update job is called with a request body setting the id, links and processed instances of the job", func() {
+
+			update, err := models.CreateJob(strings.NewReader(`{"state":"submitted","id":"456","links":{"self":{"id":"456"}},"processed_instances":[{"id":"instance1","processed_count":5}]}`))
+			So(err, ShouldBeNil)
+			err = jobService.UpdateJob(ctx, "123", update, models.AnyETag)
+
+			Convey("Then the read-only fields are listed in the error, and the job is not updated", func() {
+				So(errors.Is(err, errs.ErrReadOnlyJobFields), ShouldBeTrue)
+				So(err.Error(), ShouldEndWith, ": id, links, processed_instances")
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
 func TestService_UpdateJob_SaveFails(t *testing.T) {
 
 	Convey("Given a job service with mocked dependencies", t, func() {
 
 		mockDataStore := &mongo.DataStorer{InternalError: true}
 		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
 			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
 				return nil
 			},
@@ -333,14 +639,11 @@ func TestService_UpdateJob_SaveFails(t *testing.T) {
 		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
 
 		jobID := "123"
-		updatedJob := &models.Job{
-			RecipeID: "123-234-456",
-			ID:       jobID,
-		}
+		updatedJob := &models.Job{}
 
 		Convey("When update job is called", func() {
 
-			err := jobService.UpdateJob(ctx, jobID, updatedJob)
+			err := jobService.UpdateJob(ctx, jobID, updatedJob, models.AnyETag)
 			Convey("The expected calls are made to dependencies", func() {
 				So(err, ShouldEqual, mongo.InternalError)
 				So(len(mockedQueue.QueueCalls()), ShouldEqual, 0)
@@ -351,10 +654,33 @@ func TestService_UpdateJob_SaveFails(t *testing.T) {
 
 func TestService_UpdateJob_QueuesWhenSubmitted(t *testing.T) {
 
-	Convey("Given a job service with mocked dependencies", t, func() {
+	Convey("Given a job service with mocked dependencies and a datastore containing a created job", t, func() {
 
-		mockDataStore := &mongo.DataStorer{}
+		storedJob := &models.Job{ID: "123", RecipeID: "123-234-456", State: models.CreatedState}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				storedJob.State = update.State
+				return nil
+			},
+			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			},
+			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+			IncreaseOutboxMessageAttemptsFunc: func(ctx context.Context, id string) error {
+				return nil
+			},
+		}
 		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
 			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
 				return nil
 			},
@@ -374,20 +700,992 @@ func TestService_UpdateJob_QueuesWhenSubmitted(t *testing.T) {
 
 		jobID := "123"
 		jobUpdate := &models.Job{
-			RecipeID: "123-234-456",
-			ID:       jobID,
-			State:    "submitted",
+			State: "submitted",
 		}
 
 		Convey("When update job is called", func() {
 
-			err := jobService.UpdateJob(ctx, jobID, jobUpdate)
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
 
 			Convey("The expected calls are made to dependencies", func() {
 				So(err, ShouldBeNil)
-				So(len(mockedRecipeAPI.GetRecipeCalls()), ShouldEqual, 1)
+				So(len(mockedRecipeAPI.GetRecipeCalls()), ShouldEqual, 2)
 				So(len(mockedQueue.QueueCalls()), ShouldEqual, 1)
 			})
+
+			Convey("Then a pending outbox message is stored for the job and marked as sent once queued", func() {
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 1)
+				message := mockDataStore.AddOutboxMessageCalls()[0].Message
+				So(message.ID, ShouldNotBeBlank)
+				So(message.JobID, ShouldEqual, jobID)
+				So(message.State, ShouldEqual, models.OutboxPendingState)
+				So(mockDataStore.UpdateOutboxMessageStateCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateOutboxMessageStateCalls()[0].ID, ShouldEqual, message.ID)
+				So(mockDataStore.UpdateOutboxMessageStateCalls()[0].State, ShouldEqual, models.OutboxSentState)
+			})
+		})
+
+		Convey("When update job is called and the job cannot be queued", func() {
+			mockedQueue.QueueFunc = func(ctx context.Context, job *models.ImportData) error {
+				return errors.New("kafka is down")
+			}
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then the job is submitted and its outbox message is left pending for the relay, with the failed attempt recorded", func() {
+				So(err, ShouldBeNil)
+				So(storedJob.State, ShouldEqual, models.SubmittedState)
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateOutboxMessageStateCalls(), ShouldHaveLength, 0)
+				So(mockDataStore.IncreaseOutboxMessageAttemptsCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.IncreaseOutboxMessageAttemptsCalls()[0].ID, ShouldEqual, mockDataStore.AddOutboxMessageCalls()[0].Message.ID)
+			})
+		})
+
+		Convey("When update job is called and the job can never be queued", func() {
+			mockedQueue.ValidateFunc = func(job *models.ImportData) error {
+				return errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance 1"))
+			}
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then a job not importable error is returned, and the job is neither submitted nor recorded in the outbox", func() {
+				So(errors.Is(err, errs.ErrJobNotImportable), ShouldBeTrue)
+				So(storedJob.State, ShouldEqual, models.CreatedState)
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 0)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When update job is called and the format of the recipe is not supported", func() {
+			mockedQueue.FormatsFunc = func() []string {
+				return []string{"v4"}
+			}
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then an unsupported format error is returned and the job is not submitted", func() {
+				So(err, ShouldEqual, errs.ErrUnsupportedFormat)
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 0)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When update job is called and the outbox message cannot be stored", func() {
+			mockDataStore.AddOutboxMessageFunc = func(ctx context.Context, message *models.OutboxMessage) error {
+				return errors.New("mongo is down")
+			}
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then the error is returned and the job is not submitted", func() {
+				So(err, ShouldNotBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When update job is called and the job cannot be submitted", func() {
+			mockDataStore.UpdateJobFunc = func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return errs.ErrInvalidStateTransition
+			}
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then the error is returned and the outbox message is discarded", func() {
+				So(err, ShouldEqual, errs.ErrInvalidStateTransition)
+				So(mockDataStore.UpdateOutboxMessageStateCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateOutboxMessageStateCalls()[0].State, ShouldEqual, models.OutboxDiscardedState)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+// v4Recipe returns a v4 recipe declaring an input file with the provided description, as the recipe API would
+func v4Recipe(fileDescription string) *recipe.Recipe {
+	var r recipe.Recipe
+	b := []byte(`{"id":"123","format":"v4","files":[{"description":"` + fileDescription + `"}],"output_instances":[{"dataset_id":"cpih01"}]}`)
+	So(json.Unmarshal(b, &r), ShouldBeNil)
+	return &r
+}
+
+func TestService_GetJobReadiness_NotImportable(t *testing.T) {
+
+	Convey("Given a job service with a v4 job whose recipe does not declare any input files", t, func() {
+
+		storedJob := &models.Job{
+			ID:             "123",
+			RecipeID:       "123",
+			State:          models.CreatedState,
+			RecipeSnapshot: &models.RecipeSnapshot{ID: "123", Format: "v4", OutputInstances: []models.RecipeInstance{{DatasetID: "cpih01"}}},
+			InstanceFiles:  []models.InstanceFile{{InstanceID: "instance1", DatasetID: "cpih01"}},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc: supportedFormats,
+			ValidateFunc: func(job *models.ImportData) error {
+				return errs.ErrorJobNotImportable(errors.New("no file alias name is mapped to instance instance1"))
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When the job readiness is requested", func() {
+			readiness, err := jobService.GetJobReadiness(ctx, "123")
+
+			Convey("Then the job is not reported as ready, as it could never be imported", func() {
+				So(readiness, ShouldBeNil)
+				So(errors.Is(err, errs.ErrJobNotImportable), ShouldBeTrue)
+				So(mockedQueue.ValidateCalls(), ShouldHaveLength, 1)
+				So(mockedQueue.ValidateCalls()[0].Job.Format, ShouldEqual, "v4")
+			})
+		})
+	})
+}
+
+func TestService_UpdateJob_NotReady(t *testing.T) {
+
+	Convey("Given a job service with a created job missing the file expected by its recipe", t, func() {
+
+		storedJob := &models.Job{
+			ID:            "123",
+			RecipeID:      "123",
+			State:         models.CreatedState,
+			UploadedFiles: &[]models.UploadedFile{{AliasName: "wrong", URL: "s3://bucket/wrong.csv"}},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return v4Recipe("CPIH v4"), nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When the job readiness is requested", func() {
+			readiness, err := jobService.GetJobReadiness(ctx, "123")
+
+			Convey("Then the missing and unexpected files are reported", func() {
+				So(err, ShouldBeNil)
+				So(readiness.Ready, ShouldBeFalse)
+				So(readiness.MissingFiles, ShouldResemble, []string{"CPIH v4"})
+				So(readiness.UnexpectedFiles, ShouldResemble, []string{"wrong"})
+			})
+		})
+
+		Convey("When update job is called to submit the job", func() {
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.SubmittedState}, models.AnyETag)
+
+			Convey("Then the job is not submitted, and the error lists the files", func() {
+				So(errors.Is(err, errs.ErrJobNotReady), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "missing files: [CPIH v4], unexpected files: [wrong]")
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldBeEmpty)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When patch job is called to replace the file and submit the job", func() {
+			mockDataStore.UpdateJobFunc = func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			}
+			mockDataStore.AddOutboxMessageFunc = func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			}
+			mockDataStore.ClaimOutboxMessageFunc = func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			}
+			mockDataStore.UpdateOutboxMessageStateFunc = func(ctx context.Context, id string, state string) error {
+				return nil
+			}
+			patches := []dprequest.Patch{
+				{Op: "remove", Path: "/files/wrong"},
+				{Op: "add", Path: "/files/-", Value: map[string]interface{}{"alias_name": "CPIH v4", "url": "s3://bucket/cpih.csv"}},
+				{Op: "replace", Path: "/state", Value: models.SubmittedState},
+			}
+			err := jobService.PatchJob(ctx, "123", patches, models.AnyETag)
+
+			Convey("Then the patched files are checked, and the job is submitted", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a job service with a created job that is ready to be submitted", t, func() {
+
+		storedJob := &models.Job{
+			ID:              "123",
+			RecipeID:        "123",
+			State:           models.CreatedState,
+			UniqueTimestamp: bsonprim.Timestamp{T: 1650000000, I: 1},
+			UploadedFiles:   &[]models.UploadedFile{{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"}},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return v4Recipe("CPIH v4"), nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, &testjob.DatasetAPIClientMock{}, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When update job is called to submit the job with any eTag, and a file is removed after the job was checked", func() {
+			mockDataStore.UpdateJobFunc = func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return errs.ErrJobETagMismatch
+			}
+
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.SubmittedState}, models.AnyETag)
+
+			Convey("Then the job is only submitted on the condition that it has not changed since it was checked", func() {
+				So(err, ShouldEqual, errs.ErrJobETagMismatch)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+			})
+
+			Convey("Then the outbox message of the job is discarded", func() {
+				So(mockDataStore.UpdateOutboxMessageStateCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateOutboxMessageStateCalls()[0].State, ShouldEqual, models.OutboxDiscardedState)
+			})
+		})
+	})
+}
+
+func TestService_UpdateJob_RecipeSnapshot(t *testing.T) {
+
+	Convey("Given a created job with a snapshot of a v4 recipe that has since been changed to cantabular", t, func() {
+
+		storedJob := &models.Job{
+			ID:            "123",
+			RecipeID:      "123",
+			State:         models.CreatedState,
+			UploadedFiles: &[]models.UploadedFile{{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"}},
+			Links:         &models.LinksMap{Instances: []models.IDLink{{ID: "instance1"}}},
+			RecipeSnapshot: &models.RecipeSnapshot{
+				ID:              "123",
+				Format:          "v4",
+				InputFiles:      []string{"CPIH v4"},
+				OutputInstances: []models.RecipeInstance{{DatasetID: "cpih01"}},
+			},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				storedJob.State = update.State
+				return nil
+			},
+			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			},
+			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
+			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
+				return nil
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When the job is submitted", func() {
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.SubmittedState}, models.AnyETag)
+
+			Convey("Then the job is checked and dispatched with the format of its snapshot, without getting the recipe again", func() {
+				So(err, ShouldBeNil)
+				So(mockedRecipeAPI.GetRecipeCalls(), ShouldBeEmpty)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 1)
+				So(mockedQueue.QueueCalls()[0].Job.Format, ShouldEqual, "v4")
+			})
+		})
+	})
+}
+
+func TestService_UpdateJob_InvalidStateTransition(t *testing.T) {
+
+	Convey("Given a job service with a datastore containing a completed job", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{ID: jobID, State: models.CompletedState}, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
+			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
+				return nil
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		jobID := "123"
+		jobUpdate := &models.Job{
+			State: models.SubmittedState,
+		}
+
+		Convey("When update job is called to submit the job", func() {
+
+			err := jobService.UpdateJob(ctx, jobID, jobUpdate, models.AnyETag)
+
+			Convey("Then an invalid state transition error is returned and the job is neither stored nor queued", func() {
+				So(err, ShouldEqual, errs.ErrInvalidStateTransition)
+				So(mockDataStore.GetJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestService_UpdateJob_RepeatedTerminalState(t *testing.T) {
+
+	Convey("Given a job service with a datastore containing a completed job", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{ID: jobID, State: models.CompletedState}, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When update job is called to complete the job again", func() {
+
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.CompletedState}, models.AnyETag)
+
+			Convey("Then no error is returned, and the job is neither stored nor queued", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When update job is called to fail the completed job", func() {
+
+			err := jobService.UpdateJob(ctx, "123", &models.Job{State: models.FailedState}, models.AnyETag)
+
+			Convey("Then an invalid state transition error is returned", func() {
+				So(err, ShouldEqual, errs.ErrInvalidStateTransition)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestService_PatchJob(t *testing.T) {
+
+	Convey("Given a job service with mocked dependencies and a datastore containing a created job", t, func() {
+
+		storedJob := &models.Job{
+			ID:              "123",
+			RecipeID:        "123-234-456",
+			State:           models.CreatedState,
+			UploadedFiles:   &[]models.UploadedFile{{AliasName: "v4", URL: "s3://bucket/v4.csv"}},
+			UniqueTimestamp: bsonprim.Timestamp{T: 1650000000, I: 1},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return storedJob, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				if update.State != "" {
+					storedJob.State = update.State
+				}
+				return nil
+			},
+			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			},
+			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
+			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
+				return nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When patch job is called to add a file", func() {
+			patches := []dprequest.Patch{
+				{Op: "add", Path: "/files/-", Value: map[string]interface{}{"alias_name": "cpih", "url": "s3://bucket/cpih.csv"}},
+			}
+			err := jobService.PatchJob(ctx, "123", patches, models.AnyETag)
+
+			Convey("Then only the files are updated, on the condition that the job has not changed since it was patched", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].ETag, ShouldEqual, "1650000000-1")
+				So(mockDataStore.UpdateJobCalls()[0].Update, ShouldResemble, &models.Job{
+					UploadedFiles: &[]models.UploadedFile{
+						{AliasName: "v4", URL: "s3://bucket/v4.csv"},
+						{AliasName: "cpih", URL: "s3://bucket/cpih.csv"},
+					},
+				})
+				So(mockedQueue.QueueCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When patch job is called to submit the job", func() {
+			patches := []dprequest.Patch{{Op: "replace", Path: "/state", Value: models.SubmittedState}}
+			err := jobService.PatchJob(ctx, "123", patches, "1650000000-1")
+
+			Convey("Then the job is submitted in the same way as when it is updated", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls()[0].Update, ShouldResemble, &models.Job{State: models.SubmittedState})
+				So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 1)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When patch job is called with an invalid state", func() {
+			patches := []dprequest.Patch{{Op: "replace", Path: "/state", Value: "start"}}
+			err := jobService.PatchJob(ctx, "123", patches, models.AnyETag)
+
+			Convey("Then the invalid state error is returned and the job is not updated", func() {
+				So(err, ShouldEqual, errs.ErrInvalidState)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When patch job is called with a state the job cannot be moved to", func() {
+			patches := []dprequest.Patch{{Op: "replace", Path: "/state", Value: models.CompletedState}}
+			err := jobService.PatchJob(ctx, "123", patches, models.AnyETag)
+
+			Convey("Then the invalid state transition error is returned and the job is not updated", func() {
+				So(err, ShouldEqual, errs.ErrInvalidStateTransition)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When patch job is called with a different eTag", func() {
+			patches := []dprequest.Patch{{Op: "remove", Path: "/files/v4"}}
+			err := jobService.PatchJob(ctx, "123", patches, "1650000000-0")
+
+			Convey("Then the eTag mismatch error is returned and the job is not updated", func() {
+				So(err, ShouldEqual, errs.ErrJobETagMismatch)
+				So(mockDataStore.UpdateJobCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestService_IncreaseProcessedInstance(t *testing.T) {
+
+	Convey("Given a job service with mocked dependencies", t, func() {
+
+		mockDataStore := &mongo.DataStorer{}
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When the processed count is increased for an instance of the job", func() {
+
+			processed, err := jobService.IncreaseProcessedInstance(ctx, "34534543543", "54321", "", models.AnyETag)
+
+			Convey("Then the updated processed instances are returned", func() {
+				So(err, ShouldBeNil)
+				So(processed, ShouldResemble, []models.ProcessedInstances{
+					{
+						ID:             "54321",
+						RequiredCount:  5,
+						ProcessedCount: 1,
+					},
+				})
+			})
+		})
+
+		Convey("When the processed count is increased for an instance that is not part of the job", func() {
+
+			processed, err := jobService.IncreaseProcessedInstance(ctx, "34534543543", "inexistent", "", models.AnyETag)
+
+			Convey("Then an invalid instance ID error is returned", func() {
+				So(err, ShouldEqual, errs.ErrInvalidInstanceID)
+				So(processed, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a job service with a datastore that increases the processed count of a job", t, func() {
+
+		processed := []models.ProcessedInstances{
+			{ID: "instance1", RequiredCount: 2, ProcessedCount: 2},
+			{ID: "instance2", RequiredCount: 3, ProcessedCount: 2},
+		}
+		mockDataStore := &dsmock.DataStorerMock{
+			IncreaseProcessedInstanceFunc: func(ctx context.Context, jobID string, instanceID string, dimension string, eTag string) ([]models.ProcessedInstances, error) {
+				return processed, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When a processed dimension is reported and an instance is still being processed", func() {
+
+			result, err := jobService.IncreaseProcessedInstance(ctx, "123", "instance1", "codelist11", models.AnyETag)
+
+			Convey("Then the datastore is atomically updated and the job is not completed", func() {
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, processed)
+				So(mockDataStore.IncreaseProcessedInstanceCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.IncreaseProcessedInstanceCalls()[0].JobID, ShouldEqual, "123")
+				So(mockDataStore.IncreaseProcessedInstanceCalls()[0].InstanceID, ShouldEqual, "instance1")
+				So(mockDataStore.IncreaseProcessedInstanceCalls()[0].Dimension, ShouldEqual, "codelist11")
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the last instance reaches its required count", func() {
+			processed[1].ProcessedCount = 3
+
+			_, err := jobService.IncreaseProcessedInstance(ctx, "123", "instance2", "", models.AnyETag)
+
+			Convey("Then the job is completed with a completion time", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].JobID, ShouldEqual, "123")
+				So(mockDataStore.UpdateJobCalls()[0].Update.State, ShouldEqual, models.CompletedState)
+				So(mockDataStore.UpdateJobCalls()[0].Update.CompletedAt, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the last instance reaches its required count but the job is not submitted", func() {
+			processed[1].ProcessedCount = 3
+			mockDataStore.UpdateJobFunc = func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return errs.ErrInvalidStateTransition
+			}
+
+			result, err := jobService.IncreaseProcessedInstance(ctx, "123", "instance2", "", models.AnyETag)
+
+			Convey("Then the processed instances are returned without error", func() {
+				So(err, ShouldBeNil)
+				So(result, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the job cannot be completed", func() {
+			processed[1].ProcessedCount = 3
+			mockDataStore.UpdateJobFunc = func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return mongo.InternalError
+			}
+
+			result, err := jobService.IncreaseProcessedInstance(ctx, "123", "instance2", "", models.AnyETag)
+
+			Convey("Then the error is returned", func() {
+				So(err, ShouldEqual, mongo.InternalError)
+				So(result, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestService_CancelJob(t *testing.T) {
+
+	Convey("Given a job service with a datastore containing a submitted job with two instances", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{
+					ID:    jobID,
+					State: models.SubmittedState,
+					Links: &models.LinksMap{
+						Instances: []models.IDLink{{ID: "instance1"}, {ID: "instance2"}},
+					},
+				}, nil
+			},
+			UpdateJobFunc: func(ctx context.Context, jobID string, update *models.Job, eTag string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When cancel job is called", func() {
+
+			err := jobService.CancelJob(ctx, "123")
+
+			Convey("Then the job is stored as cancelled", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.UpdateJobCalls()[0].Update.State, ShouldEqual, models.CancelledState)
+			})
+
+			Convey("Then each instance is moved to the failed state in dataset API", func() {
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 2)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].InstanceID, ShouldEqual, "instance1")
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateFailed.String())
+				So(mockedDatasetAPI.PutInstanceCalls()[0].ServiceAuthToken, ShouldEqual, serviceAuthToken)
+				So(mockedDatasetAPI.PutInstanceCalls()[1].InstanceID, ShouldEqual, "instance2")
+				So(mockedDatasetAPI.PutInstanceCalls()[1].Instance.State, ShouldEqual, dataset.StateFailed.String())
+			})
+
+			Convey("Then nothing is queued", func() {
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When dataset API fails to update an instance", func() {
+			mockedDatasetAPI.PutInstanceFunc = func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return "", errors.New("dataset API is down")
+			}
+
+			err := jobService.CancelJob(ctx, "123")
+
+			Convey("Then the error is returned, after attempting to update every instance", func() {
+				So(err, ShouldNotBeNil)
+				So(mockDataStore.UpdateJobCalls(), ShouldHaveLength, 1)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("Given a job service with a datastore containing a completed job", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{ID: jobID, State: models.CompletedState}, nil
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+
+		jobService := job.NewService(mockDataStore, &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}, datasetAPIURL, mockedDatasetAPI, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When cancel job is called", func() {
+
+			err := jobService.CancelJob(ctx, "123")
+
+			Convey("Then an invalid state transition error is returned and no instances are updated", func() {
+				So(err, ShouldEqual, errs.ErrInvalidStateTransition)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
+
+func TestService_RetryJob(t *testing.T) {
+
+	Convey("Given a job service with a datastore containing a failed job on its second attempt", t, func() {
+
+		storedState := models.FailedState
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{
+					ID:       jobID,
+					RecipeID: "123-234-456",
+					State:    storedState,
+					Attempt:  2,
+					Links: &models.LinksMap{
+						Self:      models.IDLink{ID: jobID, HRef: "http://import-api/jobs/" + jobID},
+						Instances: []models.IDLink{{ID: "instance1"}},
+					},
+					Processed: []models.ProcessedInstances{
+						{ID: "instance1", RequiredCount: 2, ProcessedCount: 1, ProcessedDimensions: []string{"codelist11"}},
+					},
+				}, nil
+			},
+			RetryJobFunc: func(ctx context.Context, jobID string, update *models.Job) error {
+				storedState = update.State
+				return nil
+			},
+			AddOutboxMessageFunc: func(ctx context.Context, message *models.OutboxMessage) error {
+				return nil
+			},
+			ClaimOutboxMessageFunc: func(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+				return true, nil
+			},
+			UpdateOutboxMessageStateFunc: func(ctx context.Context, id string, state string) error {
+				return nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{
+			FormatsFunc:  supportedFormats,
+			ValidateFunc: validJob,
+			QueueFunc: func(ctx context.Context, job *models.ImportData) error {
+				return nil
+			},
+		}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{
+			PostInstanceFunc: func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
+				retInstance := dummyInstance()
+				retInstance.ID = "dummyInstance_" + newInstance.Links.Dataset.ID
+				return retInstance, testETag, nil
+			},
+			PutInstanceFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, collectionID string, instanceID string, i dataset.UpdateInstance, ifMatch string) (string, error) {
+				return testETag, nil
+			},
+		}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return dummyRecipe, nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		Convey("When retry job is called with the default options", func() {
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{})
+
+			Convey("Then the job is resubmitted as its third attempt with the processed counts reset, keeping its recipe", func() {
+				So(err, ShouldBeNil)
+				So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 1)
+				So(mockDataStore.RetryJobCalls()[0].JobID, ShouldEqual, "123")
+				So(mockDataStore.RetryJobCalls()[0].Update, ShouldResemble, &models.Job{
+					State:          models.SubmittedState,
+					Attempt:        3,
+					Processed:      []models.ProcessedInstances{{ID: "instance1", RequiredCount: 2}},
+					RecipeSnapshot: models.NewRecipeSnapshot(dummyRecipe),
+				})
+			})
+
+			Convey("Then no instances are created, and the existing instance is submitted and queued", func() {
+				So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 0)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 1)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].InstanceID, ShouldEqual, "instance1")
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateSubmitted.String())
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 1)
+				So(mockedQueue.QueueCalls()[0].Job.JobID, ShouldEqual, "123")
+			})
+		})
+
+		Convey("When retry job is called recreating the instances", func() {
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{RecreateInstances: true})
+
+			Convey("Then the instances defined by the recipe are posted to dataset API", func() {
+				So(err, ShouldBeNil)
+				So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 2)
+				So(mockedDatasetAPI.PostInstanceCalls()[0].NewInstance, ShouldResemble, expectedNewInstance("123", "dataset1"))
+				So(mockedDatasetAPI.PostInstanceCalls()[1].NewInstance, ShouldResemble, expectedNewInstance("123", "dataset2"))
+			})
+
+			Convey("Then the job is resubmitted with the links and processed counts of the new instances", func() {
+				So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 1)
+				update := mockDataStore.RetryJobCalls()[0].Update
+				So(update.State, ShouldEqual, models.SubmittedState)
+				So(update.Attempt, ShouldEqual, 3)
+				So(update.Links.Instances, ShouldResemble, []models.IDLink{
+					{ID: "dummyInstance_dataset1", HRef: "http://dataset-api/instances/dummyInstance_dataset1"},
+					{ID: "dummyInstance_dataset2", HRef: "http://dataset-api/instances/dummyInstance_dataset2"},
+				})
+				So(update.Processed, ShouldResemble, []models.ProcessedInstances{
+					{ID: "dummyInstance_dataset1", RequiredCount: 2},
+					{ID: "dummyInstance_dataset2", RequiredCount: 3},
+				})
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("Then the instance of the previous attempt is moved to the failed state in dataset API", func() {
+				var failed []string
+				for _, call := range mockedDatasetAPI.PutInstanceCalls() {
+					if call.Instance.State == dataset.StateFailed.String() {
+						failed = append(failed, call.InstanceID)
+					}
+				}
+				So(failed, ShouldResemble, []string{"instance1"})
+			})
+		})
+
+		Convey("When retry job is called recreating the instances and dataset API fails to create one", func() {
+			mockedDatasetAPI.PostInstanceFunc = func(ctx context.Context, serviceAuthToken string, newInstance *dataset.NewInstance) (*dataset.Instance, string, error) {
+				return nil, "", errors.New("dataset API is down")
+			}
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{RecreateInstances: true})
+
+			Convey("Then the error is returned and the job is neither updated nor queued", func() {
+				So(err, ShouldResemble, job.ErrCreateInstanceFailed("dataset1"))
+				So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When retry job is called recreating the instances and the job is retried concurrently by a different caller", func() {
+			mockDataStore.RetryJobFunc = func(ctx context.Context, jobID string, update *models.Job) error {
+				return errs.ErrJobNotFailed
+			}
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{RecreateInstances: true})
+
+			Convey("Then the error is returned and the new instances are moved to the failed state in dataset API", func() {
+				So(err, ShouldEqual, errs.ErrJobNotFailed)
+				So(mockedDatasetAPI.PutInstanceCalls(), ShouldHaveLength, 2)
+				So(mockedDatasetAPI.PutInstanceCalls()[0].InstanceID, ShouldEqual, "dummyInstance_dataset1")
+				So(mockedDatasetAPI.PutInstanceCalls()[0].Instance.State, ShouldEqual, dataset.StateFailed.String())
+				So(mockedDatasetAPI.PutInstanceCalls()[1].InstanceID, ShouldEqual, "dummyInstance_dataset2")
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the job is retried concurrently by a different caller", func() {
+			mockDataStore.RetryJobFunc = func(ctx context.Context, jobID string, update *models.Job) error {
+				return errs.ErrJobNotFailed
+			}
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{})
+
+			Convey("Then the error is returned and nothing is queued", func() {
+				So(err, ShouldEqual, errs.ErrJobNotFailed)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a job service with a datastore containing a failed v4 job missing the file expected by its recipe", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{
+					ID:            jobID,
+					RecipeID:      "123",
+					State:         models.FailedState,
+					UploadedFiles: &[]models.UploadedFile{{AliasName: "wrong", URL: "s3://bucket/wrong.csv"}},
+					Links:         &models.LinksMap{Instances: []models.IDLink{{ID: "instance1"}}},
+					InstanceFiles: []models.InstanceFile{{InstanceID: "instance1", DatasetID: "cpih01", AliasName: "CPIH v4"}},
+				}, nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+		mockedDatasetAPI := &testjob.DatasetAPIClientMock{}
+		mockedRecipeAPI := &testjob.RecipeAPIClientMock{
+			GetRecipeFunc: func(ctx context.Context, userAuthToken string, serviceAuthToken string, recipeID string) (*recipe.Recipe, error) {
+				return v4Recipe("CPIH v4"), nil
+			},
+		}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, mockedDatasetAPI, mockedRecipeAPI, urlBuilder, serviceAuthToken)
+
+		for _, options := range []*models.RetryOptions{{}, {RecreateInstances: true}} {
+			Convey(fmt.Sprintf("When retry job is called with recreate instances %t", options.RecreateInstances), func() {
+
+				err := jobService.RetryJob(ctx, "123", options)
+
+				Convey("Then the job cannot be retried, and the error lists the files", func() {
+					So(errors.Is(err, errs.ErrJobNotRetriable), ShouldBeTrue)
+					So(errors.Is(err, errs.ErrJobNotReady), ShouldBeTrue)
+					So(err.Error(), ShouldContainSubstring, "missing files: [CPIH v4], unexpected files: [wrong]")
+				})
+
+				Convey("Then no instance is created, and the job is neither resubmitted nor recorded in the outbox", func() {
+					So(mockedDatasetAPI.PostInstanceCalls(), ShouldHaveLength, 0)
+					So(mockDataStore.AddOutboxMessageCalls(), ShouldHaveLength, 0)
+					So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 0)
+				})
+			})
+		}
+
+		Convey("When retry job is called and the events of the job could never be queued", func() {
+			mockDataStore.GetJobFunc = func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{ID: jobID, RecipeID: "123", State: models.FailedState,
+					UploadedFiles: &[]models.UploadedFile{{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"}}}, nil
+			}
+			mockedQueue.ValidateFunc = func(job *models.ImportData) error {
+				return errs.ErrorJobNotImportable(errors.New("InstanceIds must not be empty"))
+			}
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{})
+
+			Convey("Then the job cannot be retried, and it is not resubmitted", func() {
+				So(errors.Is(err, errs.ErrJobNotRetriable), ShouldBeTrue)
+				So(errors.Is(err, errs.ErrJobNotImportable), ShouldBeTrue)
+				So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a job service with a datastore containing a submitted job", t, func() {
+
+		mockDataStore := &dsmock.DataStorerMock{
+			GetJobFunc: func(ctx context.Context, jobID string) (*models.Job, error) {
+				return &models.Job{ID: jobID, State: models.SubmittedState}, nil
+			},
+		}
+		mockedQueue := &testjob.QueueMock{FormatsFunc: supportedFormats, ValidateFunc: validJob}
+
+		jobService := job.NewService(mockDataStore, mockedQueue, datasetAPIURL, &testjob.DatasetAPIClientMock{}, &testjob.RecipeAPIClientMock{}, urlBuilder, serviceAuthToken)
+
+		Convey("When retry job is called", func() {
+
+			err := jobService.RetryJob(ctx, "123", &models.RetryOptions{})
+
+			Convey("Then a job not failed error is returned and nothing is queued", func() {
+				So(err, ShouldEqual, errs.ErrJobNotFailed)
+				So(mockDataStore.RetryJobCalls(), ShouldHaveLength, 0)
+				So(mockedQueue.QueueCalls(), ShouldHaveLength, 0)
+			})
 		})
 	})
 }
@@ -11,7 +11,9 @@ import (
 )
 
 var (
-	lockQueueMockQueue sync.RWMutex
+	lockQueueMockFormats  sync.RWMutex
+	lockQueueMockQueue    sync.RWMutex
+	lockQueueMockValidate sync.RWMutex
 )
 
 // Ensure, that QueueMock does implement job.Queue.
@@ -24,9 +26,15 @@ var _ job.Queue = &QueueMock{}
 //
 //         // make and configure a mocked job.Queue
 //         mockedQueue := &QueueMock{
+//             FormatsFunc: func() []string {
+// 	               panic("mock out the Formats method")
+//             },
 //             QueueFunc: func(ctx context.Context, job *models.ImportData) error {
 // 	               panic("mock out the Queue method")
 //             },
+//             ValidateFunc: func(job *models.ImportData) error {
+// 	               panic("mock out the Validate method")
+//             },
 //         }
 //
 //         // use mockedQueue in code that requires job.Queue
@@ -34,11 +42,20 @@ var _ job.Queue = &QueueMock{}
 //
 //     }
 type QueueMock struct {
+	// FormatsFunc mocks the Formats method.
+	FormatsFunc func() []string
+
 	// QueueFunc mocks the Queue method.
 	QueueFunc func(ctx context.Context, job *models.ImportData) error
 
+	// ValidateFunc mocks the Validate method.
+	ValidateFunc func(job *models.ImportData) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// Formats holds details about calls to the Formats method.
+		Formats []struct {
+		}
 		// Queue holds details about calls to the Queue method.
 		Queue []struct {
 			// Ctx is the ctx argument value.
@@ -46,7 +63,38 @@ type QueueMock struct {
 			// Job is the job argument value.
 			Job *models.ImportData
 		}
+		// Validate holds details about calls to the Validate method.
+		Validate []struct {
+			// Job is the job argument value.
+			Job *models.ImportData
+		}
+	}
+}
+
+// Formats calls FormatsFunc.
+func (mock *QueueMock) Formats() []string {
+	if mock.FormatsFunc == nil {
+		panic("QueueMock.FormatsFunc: method is nil but Queue.Formats was just called")
+	}
+	callInfo := struct {
+	}{}
+	lockQueueMockFormats.Lock()
+	mock.calls.Formats = append(mock.calls.Formats, callInfo)
+	lockQueueMockFormats.Unlock()
+	return mock.FormatsFunc()
+}
+
+// FormatsCalls gets all the calls that were made to Formats.
+// Check the length with:
+//     len(mockedQueue.FormatsCalls())
+func (mock *QueueMock) FormatsCalls() []struct {
+} {
+	var calls []struct {
 	}
+	lockQueueMockFormats.RLock()
+	calls = mock.calls.Formats
+	lockQueueMockFormats.RUnlock()
+	return calls
 }
 
 // Queue calls QueueFunc.
@@ -83,3 +131,34 @@ func (mock *QueueMock) QueueCalls() []struct {
 	lockQueueMockQueue.RUnlock()
 	return calls
 }
+
+// Validate calls ValidateFunc.
+func (mock *QueueMock) Validate(job *models.ImportData) error {
+	if mock.ValidateFunc == nil {
+		panic("QueueMock.ValidateFunc: method is nil but Queue.Validate was just called")
+	}
+	callInfo := struct {
+		Job *models.ImportData
+	}{
+		Job: job,
+	}
+	lockQueueMockValidate.Lock()
+	mock.calls.Validate = append(mock.calls.Validate, callInfo)
+	lockQueueMockValidate.Unlock()
+	return mock.ValidateFunc(job)
+}
+
+// ValidateCalls gets all the calls that were made to Validate.
+// Check the length with:
+//     len(mockedQueue.ValidateCalls())
+func (mock *QueueMock) ValidateCalls() []struct {
+	Job *models.ImportData
+} {
+	var calls []struct {
+		Job *models.ImportData
+	}
+	lockQueueMockValidate.RLock()
+	calls = mock.calls.Validate
+	lockQueueMockValidate.RUnlock()
+	return calls
+}
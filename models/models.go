@@ -1,14 +1,21 @@
 package models
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/dataset"
 	"github.com/ONSdigital/dp-api-clients-go/v2/recipe"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -18,6 +25,16 @@ const (
 	CreatedState   = "created"
 	SubmittedState = "submitted"
 	FailedState    = "failed"
+	CancelledState = "cancelled"
+)
+
+// The states of an outbox message
+const (
+	OutboxPendingState   = "pending"
+	OutboxSendingState   = "sending"
+	OutboxSentState      = "sent"
+	OutboxDiscardedState = "discarded"
+	OutboxFailedState    = "failed"
 )
 
 var validStates = map[string]bool{
@@ -25,27 +42,193 @@ var validStates = map[string]bool{
 	CreatedState:   true,
 	SubmittedState: true,
 	FailedState:    true,
+	CancelledState: true,
+}
+
+// validTransitions maps each job state to the states a job can be moved to from it.
+// A cancelled job can be cancelled again, so that a cancellation that failed part-way through can be retried.
+var validTransitions = map[string][]string{
+	CreatedState:   {CreatedState, SubmittedState, FailedState, CancelledState},
+	SubmittedState: {CompletedState, FailedState, CancelledState},
+	CompletedState: {},
+	FailedState:    {},
+	CancelledState: {CancelledState},
+}
+
+// terminalStates are the states a job cannot be moved out of. Reporting one of them again for a job that is
+// already in it is accepted, but it has no effect.
+var terminalStates = map[string]bool{
+	CompletedState: true,
+	FailedState:    true,
+}
+
+// updatableJobFields are the json names of the job fields that clients are allowed to update.
+// Every other field is managed by the import API.
+var updatableJobFields = map[string]bool{
+	"state": true,
 }
 
 // JobResults for list of Job items
 type JobResults struct {
-	Count      int    `json:"count"`
-	Offset     int    `json:"offset"`
-	Limit      int    `json:"limit"`
-	TotalCount int    `json:"total_count"`
-	Items      []*Job `json:"items"`
+	Count      int              `json:"count"`
+	Offset     int              `json:"offset"`
+	Limit      int              `json:"limit"`
+	TotalCount int              `json:"total_count"`
+	Items      []*Job           `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Links      *JobResultsLinks `json:"links,omitempty"`
+}
+
+// JobResultsLinks represents the links related to a list of jobs
+type JobResultsLinks struct {
+	Next *Link `json:"next,omitempty"`
+}
+
+// Link represents a link to a resource
+type Link struct {
+	HRef string `json:"href"`
+}
+
+// JobCursor is the position of the last job of a page of jobs listed in last updated order. It is given to clients
+// as an opaque string, and the following page starts after the job it identifies.
+type JobCursor struct {
+	LastUpdated time.Time `json:"last_updated"`
+	ID          string    `json:"id"`
+}
+
+// Encode returns the opaque string representing the cursor
+func (c *JobCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseJobCursor returns the cursor represented by an opaque string created by Encode
+func ParseJobCursor(value string) (*JobCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errs.ErrorInvalidQueryParameter("invalid cursor")
+	}
+	var cursor JobCursor
+	if err = json.Unmarshal(b, &cursor); err != nil || cursor.ID == "" {
+		return nil, errs.ErrorInvalidQueryParameter("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// JobFilter holds the criteria used to select jobs from a list. Every criterion that is set must match, and a
+// criterion holding several values matches a job that has any one of them.
+type JobFilter struct {
+	States          []string
+	RecipeIDs       []string
+	DatasetIDs      []string
+	InstanceIDs     []string
+	FileAliasNames  []string
+	FileURLs        []string
+	LastUpdatedFrom *time.Time
+	LastUpdatedTo   *time.Time
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+}
+
+// Validate checks the states of the filter are valid and that its date ranges do not end before they start
+func (f *JobFilter) Validate() error {
+	for _, state := range f.States {
+		if !validStates[state] {
+			return errs.ErrInvalidState
+		}
+	}
+	if f.LastUpdatedFrom != nil && f.LastUpdatedTo != nil && f.LastUpdatedTo.Before(*f.LastUpdatedFrom) {
+		return errs.ErrorInvalidQueryParameter("last_updated_to is before last_updated_from")
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedTo.Before(*f.CreatedFrom) {
+		return errs.ErrorInvalidQueryParameter("created_to is before created_from")
+	}
+	return nil
+}
+
+// sortableJobFields are the fields the list of jobs can be sorted by. They are the json names of the job fields,
+// apart from created, which orders the jobs by the time they were created.
+var sortableJobFields = map[string]bool{
+	"id":           true,
+	"recipe":       true,
+	"state":        true,
+	"last_updated": true,
+	"completed_at": true,
+	"created":      true,
+}
+
+// JobSort is one of the fields the list of jobs is sorted by
+type JobSort struct {
+	Field      string
+	Descending bool
+}
+
+// ParseJobSort parses a comma separated list of fields to sort jobs by, in order of precedence. Each field can be
+// followed by ':asc' or ':desc', and it is sorted in ascending order if neither is provided. Eg last_updated:desc,state
+func ParseJobSort(value string) ([]JobSort, error) {
+	var sort []JobSort
+	sorted := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		field, direction, _ := strings.Cut(item, ":")
+		if !sortableJobFields[field] {
+			return nil, errs.ErrorInvalidQueryParameter("cannot sort by '" + field + "'")
+		}
+		if sorted[field] {
+			return nil, errs.ErrorInvalidQueryParameter("cannot sort by '" + field + "' more than once")
+		}
+		sorted[field] = true
+
+		switch direction {
+		case "", "asc":
+			sort = append(sort, JobSort{Field: field})
+		case "desc":
+			sort = append(sort, JobSort{Field: field, Descending: true})
+		default:
+			return nil, errs.ErrorInvalidQueryParameter("invalid sort direction '" + direction + "', it must be asc or desc")
+		}
+	}
+	return sort, nil
+}
+
+// ParseJobFields parses a comma separated list of the json names of job fields, to return only those fields of jobs
+func ParseJobFields(value string) ([]string, error) {
+	fields := strings.Split(value, ",")
+	for _, field := range fields {
+		if !isJobField(field) {
+			return nil, errs.ErrorInvalidQueryParameter("unknown job field '" + field + "'")
+		}
+	}
+	return fields, nil
+}
+
+// isJobField returns true if the provided name is the json name of a job field that is returned to clients
+func isJobField(name string) bool {
+	t := reflect.TypeOf(Job{})
+	for i := 0; i < t.NumField(); i++ {
+		jsonName := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if jsonName != "" && jsonName != "-" && jsonName == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Job for importing datasets
 type Job struct {
-	ID              string               `bson:"id,omitempty"                  json:"id,omitempty"`
-	RecipeID        string               `bson:"recipe,omitempty"              json:"recipe,omitempty"`
-	State           string               `bson:"state,omitempty"               json:"state,omitempty"`
-	UploadedFiles   *[]UploadedFile      `bson:"files,omitempty"               json:"files,omitempty"`
-	Links           *LinksMap            `bson:"links,omitempty"               json:"links,omitempty"`
-	Processed       []ProcessedInstances `bson:"processed_instances,omitempty" json:"processed_instances,omitempty"`
-	LastUpdated     time.Time            `bson:"last_updated,omitempty"        json:"last_updated,omitempty"`
-	UniqueTimestamp bsonprim.Timestamp   `bson:"unique_timestamp,omitempty"    json:"-"`
+	ID                   string               `bson:"id,omitempty"                     json:"id,omitempty"`
+	RecipeID             string               `bson:"recipe,omitempty"                 json:"recipe,omitempty"`
+	State                string               `bson:"state,omitempty"                  json:"state,omitempty"`
+	UploadedFiles        *[]UploadedFile      `bson:"files,omitempty"                  json:"files,omitempty"`
+	Links                *LinksMap            `bson:"links,omitempty"                  json:"links,omitempty"`
+	Processed            []ProcessedInstances `bson:"processed_instances,omitempty"    json:"processed_instances,omitempty"`
+	LastUpdated          time.Time            `bson:"last_updated,omitempty"           json:"last_updated,omitzero"`
+	CompletedAt          *time.Time           `bson:"completed_at,omitempty"           json:"completed_at,omitempty"`
+	Attempt              int                  `bson:"attempt,omitempty"                json:"attempt,omitempty"`
+	InstanceFiles        []InstanceFile       `bson:"instance_files,omitempty"         json:"instance_files,omitempty"`
+	RecipeSnapshot       *RecipeSnapshot      `bson:"recipe_snapshot,omitempty"        json:"recipe_snapshot,omitempty"`
+	IdempotencyKey       string               `bson:"idempotency_key,omitempty"        json:"-"`
+	IdempotencyKeyExpiry *time.Time           `bson:"idempotency_key_expiry,omitempty" json:"-"`
+	UniqueTimestamp      bsonprim.Timestamp   `bson:"unique_timestamp,omitempty"       json:"-"`
 }
 
 // LinksMap represents a list of links related to a job resource
@@ -54,6 +237,40 @@ type LinksMap struct {
 	Self      IDLink   `bson:"self,omitempty" json:"self,omitempty"`
 }
 
+// FormatResults for the list of job formats that can be imported
+type FormatResults struct {
+	Count int      `json:"count"`
+	Items []string `json:"items"`
+}
+
+// AnyETag is the If-Match value that updates a job whatever its current eTag
+const AnyETag = "*"
+
+// ETag returns the eTag of the job, derived from the unique timestamp that is updated on every write of the job
+func (job *Job) ETag() string {
+	return fmt.Sprintf("%d-%d", job.UniqueTimestamp.T, job.UniqueTimestamp.I)
+}
+
+// ParseETag returns the unique timestamp that the provided job eTag was derived from
+func ParseETag(eTag string) (bsonprim.Timestamp, error) {
+	var timestamp bsonprim.Timestamp
+	parts := strings.Split(eTag, "-")
+	if len(parts) != 2 {
+		return timestamp, errs.ErrJobETagMismatch
+	}
+
+	t, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return timestamp, errs.ErrJobETagMismatch
+	}
+	i, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return timestamp, errs.ErrJobETagMismatch
+	}
+
+	return bsonprim.Timestamp{T: uint32(t), I: uint32(i)}, nil
+}
+
 // Validate the content of a job
 func (job *Job) Validate() error {
 	if job.RecipeID == "" {
@@ -81,6 +298,80 @@ func (job *Job) ValidateState() error {
 	return nil
 }
 
+// ReadOnlyFields returns the json names of the fields set in this job that clients are not allowed to update
+func (job *Job) ReadOnlyFields() []string {
+	var fields []string
+	v := reflect.ValueOf(job).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" || updatableJobFields[name] {
+			continue
+		}
+		if !v.Field(i).IsZero() {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// ValidateUpdate checks that only the fields clients are allowed to update are set in this job
+func (job *Job) ValidateUpdate() error {
+	if fields := job.ReadOnlyFields(); len(fields) > 0 {
+		return errs.ErrorReadOnlyJobFields(fields)
+	}
+	return nil
+}
+
+// ValidateTransition checks that a job in the provided current state can be moved to the state of this job.
+// An empty target state means the state is not being changed, so it is always allowed.
+func (job *Job) ValidateTransition(currentState string) error {
+	if job.State == "" {
+		return nil
+	}
+
+	for _, state := range validTransitions[currentState] {
+		if state == job.State {
+			return nil
+		}
+	}
+
+	return errs.ErrInvalidStateTransition
+}
+
+// IsRepeatedTerminalState returns true if this job update sets the terminal state a job in the provided current
+// state is already in. Such an update is not a transition, so it must not be stored nor trigger anything.
+func (job *Job) IsRepeatedTerminalState(currentState string) bool {
+	return job.State == currentState && terminalStates[currentState]
+}
+
+// IsProcessed returns true if every one of the provided instances has processed its required count
+func IsProcessed(processed []ProcessedInstances) bool {
+	if len(processed) == 0 {
+		return false
+	}
+	for _, instance := range processed {
+		if instance.ProcessedCount < instance.RequiredCount {
+			return false
+		}
+	}
+	return true
+}
+
+// PreviousStates returns the list of states from which a job can be moved to the provided state
+func PreviousStates(state string) []string {
+	previous := []string{}
+	for from, targets := range validTransitions {
+		for _, to := range targets {
+			if to == state {
+				previous = append(previous, from)
+				break
+			}
+		}
+	}
+	sort.Strings(previous)
+	return previous
+}
+
 // UploadedFile used for a file which has been uploaded to a bucket
 type UploadedFile struct {
 	AliasName string `bson:"alias_name" json:"alias_name" avro:"alias-name"`
@@ -95,6 +386,13 @@ func (s UploadedFile) Validate() error {
 	return nil
 }
 
+// InstanceFile maps an instance created for a job to its dataset and to the alias name of the uploaded file it is imported from
+type InstanceFile struct {
+	InstanceID string `bson:"instance_id"          json:"instance_id"`
+	DatasetID  string `bson:"dataset_id,omitempty" json:"dataset_id,omitempty"`
+	AliasName  string `bson:"alias_name"           json:"alias_name"`
+}
+
 // ImportData used to create a message to data baker or direct to the dimension-extractor
 type ImportData struct {
 	JobID         string
@@ -102,6 +400,198 @@ type ImportData struct {
 	Format        string          `json:"format,omitempty"`
 	UploadedFiles *[]UploadedFile `json:"files,omitempty"`
 	InstanceIDs   []string
+	InstanceFiles []InstanceFile `json:"instance_files,omitempty"`
+}
+
+// UploadedFileForInstance returns the uploaded file that the provided instance is imported from, by the alias name
+// mapped to the instance. A job with a single instance and a single uploaded file is imported from that file whatever
+// its alias name, as jobs created before files were mapped to instances do not have a mapping.
+func (d *ImportData) UploadedFileForInstance(instanceID string) (*UploadedFile, error) {
+	var files []UploadedFile
+	if d.UploadedFiles != nil {
+		files = *d.UploadedFiles
+	}
+
+	if len(d.InstanceIDs) == 1 && len(files) == 1 && d.InstanceIDs[0] == instanceID {
+		return &files[0], nil
+	}
+
+	aliasName := ""
+	for _, instanceFile := range d.InstanceFiles {
+		if instanceFile.InstanceID == instanceID {
+			aliasName = instanceFile.AliasName
+			break
+		}
+	}
+	if aliasName == "" {
+		return nil, fmt.Errorf("no file alias name is mapped to instance %s", instanceID)
+	}
+
+	for i := range files {
+		if files[i].AliasName == aliasName {
+			return &files[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no uploaded file with alias name %q for instance %s", aliasName, instanceID)
+}
+
+// DatasetIDForInstance returns the ID of the dataset that the provided instance was created for. An empty string is
+// returned for the instances of jobs created before instances were mapped to their datasets.
+func (d *ImportData) DatasetIDForInstance(instanceID string) string {
+	for _, instanceFile := range d.InstanceFiles {
+		if instanceFile.InstanceID == instanceID {
+			return instanceFile.DatasetID
+		}
+	}
+	return ""
+}
+
+// Readiness describes whether the uploaded files of a job match the input files expected by its recipe,
+// so that the job can be submitted
+type Readiness struct {
+	Ready           bool     `json:"ready"`
+	MissingFiles    []string `json:"missing_files"`
+	UnexpectedFiles []string `json:"unexpected_files"`
+}
+
+// RecipeSnapshot is a copy of the recipe a job was created from. It is stored with the job, so that the job is
+// imported with the format and codelists its instances were built for, even if the recipe is changed afterwards.
+type RecipeSnapshot struct {
+	ID              string           `bson:"id"                         json:"id"`
+	Format          string           `bson:"format"                     json:"format"`
+	InputFiles      []string         `bson:"input_files,omitempty"      json:"input_files,omitempty"`
+	OutputInstances []RecipeInstance `bson:"output_instances,omitempty" json:"output_instances,omitempty"`
+}
+
+// RecipeInstance is a copy of one of the output instances of a recipe
+type RecipeInstance struct {
+	DatasetID       string            `bson:"dataset_id"                 json:"dataset_id"`
+	CodeLists       []recipe.CodeList `bson:"code_lists,omitempty"       json:"code_lists,omitempty"`
+	LowestGeography string            `bson:"lowest_geography,omitempty" json:"lowest_geography,omitempty"`
+}
+
+// NewRecipeSnapshot creates a snapshot of the provided recipe. The input files of the recipe are recorded by the
+// alias name they are uploaded with, which is their description.
+func NewRecipeSnapshot(jobRecipe *recipe.Recipe) *RecipeSnapshot {
+	snapshot := &RecipeSnapshot{
+		ID:              jobRecipe.ID,
+		Format:          jobRecipe.Format,
+		InputFiles:      []string{},
+		OutputInstances: []RecipeInstance{},
+	}
+	for _, inputFile := range jobRecipe.InputFiles {
+		if inputFile.Description != "" {
+			snapshot.InputFiles = append(snapshot.InputFiles, inputFile.Description)
+		}
+	}
+	for _, oi := range jobRecipe.OutputInstances {
+		snapshot.OutputInstances = append(snapshot.OutputInstances, RecipeInstance{
+			DatasetID:       oi.DatasetID,
+			CodeLists:       oi.CodeLists,
+			LowestGeography: oi.LowestGeography,
+		})
+	}
+	return snapshot
+}
+
+// ValidateMapping checks that the input files declared by the recipe can be mapped to its output instances: a recipe
+// declares no input file, a single input file imported by every output instance, or an input file per output instance.
+func (s *RecipeSnapshot) ValidateMapping() error {
+	if len(s.InputFiles) > 1 && len(s.InputFiles) != len(s.OutputInstances) {
+		return errs.ErrorUnmappableRecipe(len(s.InputFiles), len(s.OutputInstances))
+	}
+	return nil
+}
+
+// InstanceAliasName returns the alias name of the uploaded file that the output instance at the provided index of the
+// recipe is imported from: the only input file declared by the recipe, or the input file at the same index if the recipe
+// declares one per output instance. The output instances of recipes that do not declare any input files, such as cantabular
+// recipes, are not imported from an uploaded file, so an empty alias name is returned. An error is returned if the input
+// files of the recipe cannot be mapped to its output instances.
+func (s *RecipeSnapshot) InstanceAliasName(i int) (string, error) {
+	if err := s.ValidateMapping(); err != nil {
+		return "", err
+	}
+
+	switch len(s.InputFiles) {
+	case 0:
+		return "", nil
+	case 1:
+		return s.InputFiles[0], nil
+	default:
+		return s.InputFiles[i], nil
+	}
+}
+
+// CheckReadiness compares the alias names of the uploaded files of this job with the input files declared by the
+// provided recipe snapshot and with the files mapped to the instances of the job. Recipes that do not declare any input files,
+// such as cantabular recipes, do not import from uploaded files, so their jobs are ready as far as files are concerned;
+// whether the job can be imported without files depends on its format. The input files of the recipe must be mappable to its
+// output instances, see ValidateMapping.
+func (job *Job) CheckReadiness(jobRecipe *RecipeSnapshot) *Readiness {
+	readiness := &Readiness{MissingFiles: []string{}, UnexpectedFiles: []string{}}
+
+	expected := append([]string{}, jobRecipe.InputFiles...)
+	if len(expected) == 0 {
+		readiness.Ready = true
+		return readiness
+	}
+	for _, instanceFile := range job.InstanceFiles {
+		if instanceFile.AliasName != "" {
+			expected = append(expected, instanceFile.AliasName)
+		}
+	}
+
+	uploaded := map[string]bool{}
+	if job.UploadedFiles != nil {
+		for _, file := range *job.UploadedFiles {
+			uploaded[file.AliasName] = true
+		}
+	}
+
+	isExpected := map[string]bool{}
+	for _, aliasName := range expected {
+		if !isExpected[aliasName] && !uploaded[aliasName] {
+			readiness.MissingFiles = append(readiness.MissingFiles, aliasName)
+		}
+		isExpected[aliasName] = true
+	}
+
+	if job.UploadedFiles != nil {
+		for _, file := range *job.UploadedFiles {
+			if !isExpected[file.AliasName] {
+				readiness.UnexpectedFiles = append(readiness.UnexpectedFiles, file.AliasName)
+				isExpected[file.AliasName] = true
+			}
+		}
+	}
+
+	readiness.Ready = len(readiness.MissingFiles) == 0 && len(readiness.UnexpectedFiles) == 0
+	return readiness
+}
+
+// ValidateReadiness checks that the job is ready to be submitted, returning an error listing the missing
+// and unexpected files otherwise
+func (r *Readiness) ValidateReadiness() error {
+	if r.Ready {
+		return nil
+	}
+	return errs.ErrorJobNotReady(r.MissingFiles, r.UnexpectedFiles)
+}
+
+// OutboxMessage records that the import events of a job must be sent to kafka. It is stored before the job is
+// submitted, and it stays pending until the events have been produced, so that they are sent at least once.
+// Attempts counts the failed attempts to send the events. A message is claimed, by moving it to the sending state,
+// by the process sending it, and sent or discarded messages expire once they are no longer needed.
+type OutboxMessage struct {
+	ID          string     `bson:"id"                     json:"id"`
+	JobID       string     `bson:"job_id"                 json:"job_id"`
+	State       string     `bson:"state"                  json:"state"`
+	CreatedAt   time.Time  `bson:"created_at"             json:"created_at"`
+	LastUpdated time.Time  `bson:"last_updated,omitempty" json:"last_updated,omitempty"`
+	Attempts    int        `bson:"attempts,omitempty"     json:"attempts,omitempty"`
+	ClaimedAt   *time.Time `bson:"claimed_at,omitempty"   json:"claimed_at,omitempty"`
+	ExpiresAt   *time.Time `bson:"expires_at,omitempty"   json:"expires_at,omitempty"`
 }
 
 // DataBakerEvent used to trigger the databaker process
@@ -109,6 +599,15 @@ type DataBakerEvent struct {
 	JobID string `avro:"job_id"`
 }
 
+// CantabularDatasetInstanceStartedEvent used to trigger the import of an instance of a Cantabular dataset
+type CantabularDatasetInstanceStartedEvent struct {
+	RecipeID       string `avro:"recipe_id"`
+	InstanceID     string `avro:"instance_id"`
+	JobID          string `avro:"job_id"`
+	CantabularType string `avro:"cantabular_type"`
+	DatasetID      string `avro:"dataset_id"`
+}
+
 // IDLink holds the ID and a link to the resource
 type IDLink struct {
 	ID   string `json:"id"`
@@ -117,9 +616,28 @@ type IDLink struct {
 
 // ProcessedInstances holds the ID and the number of code lists that have been processed during an import process for an instance
 type ProcessedInstances struct {
-	ID             string `bson:"id,omitempty"               json:"id,omitempty"`
-	RequiredCount  int    `bson:"required_count,omitempty"   json:"required_count,omitempty"`
-	ProcessedCount int    `bson:"processed_count,omitempty"  json:"processed_count,omitempty"`
+	ID                  string   `bson:"id,omitempty"                   json:"id,omitempty"`
+	RequiredCount       int      `bson:"required_count,omitempty"       json:"required_count,omitempty"`
+	ProcessedCount      int      `bson:"processed_count,omitempty"      json:"processed_count,omitempty"`
+	ProcessedDimensions []string `bson:"processed_dimensions,omitempty" json:"processed_dimensions,omitempty"`
+}
+
+// ProcessedDimension identifies the codelist ID or dimension name that has been processed for an instance
+type ProcessedDimension struct {
+	Dimension string `json:"dimension"`
+}
+
+// Validate the content of the structure
+func (p ProcessedDimension) Validate() error {
+	if p.Dimension == "" {
+		return errs.ErrInvalidProcessedDimension
+	}
+	return nil
+}
+
+// RetryOptions holds the options used when retrying a failed job
+type RetryOptions struct {
+	RecreateInstances bool `json:"recreate_instances,omitempty"`
 }
 
 // CreateJob from a json message
@@ -150,6 +668,179 @@ func CreateUploadedFile(reader io.Reader) (*UploadedFile, error) {
 	return &message, message.Validate()
 }
 
+// CreateProcessedDimension from an optional json message. If the message is empty, nil is returned without error.
+func CreateProcessedDimension(reader io.Reader) (*ProcessedDimension, error) {
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errs.ErrFailedToReadRequestBody
+	}
+	if len(strings.TrimSpace(string(bytes))) == 0 {
+		return nil, nil
+	}
+	var message ProcessedDimension
+	err = json.Unmarshal(bytes, &message)
+	if err != nil {
+		return nil, errs.ErrFailedToParseJSONBody
+	}
+	return &message, message.Validate()
+}
+
+// CreateRetryOptions from an optional json message. If the message is empty, the default options are returned.
+func CreateRetryOptions(reader io.Reader) (*RetryOptions, error) {
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errs.ErrFailedToReadRequestBody
+	}
+	var options RetryOptions
+	if len(strings.TrimSpace(string(bytes))) == 0 {
+		return &options, nil
+	}
+	err = json.Unmarshal(bytes, &options)
+	if err != nil {
+		return nil, errs.ErrFailedToParseJSONBody
+	}
+	return &options, nil
+}
+
+// JobPatchOps are the patch operations supported on a job
+var JobPatchOps = []dprequest.PatchOp{
+	dprequest.OpAdd,
+	dprequest.OpRemove,
+	dprequest.OpReplace,
+}
+
+// jsonPointerUnescaper unescapes the reference tokens of a json pointer, as defined by RFC 6901
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// CreateJobPatches reads a list of patch operations from a json-patch message and validates each of them
+func CreateJobPatches(reader io.Reader) ([]dprequest.Patch, error) {
+	bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errs.ErrFailedToReadRequestBody
+	}
+	var patches []dprequest.Patch
+	err = json.Unmarshal(bytes, &patches)
+	if err != nil {
+		return nil, errs.ErrFailedToParseJSONBody
+	}
+	if len(patches) == 0 {
+		return nil, errs.ErrorInvalidPatch("no patch operations were provided")
+	}
+	for _, patch := range patches {
+		if err := patch.Validate(JobPatchOps...); err != nil {
+			return nil, errs.ErrorInvalidPatch(err.Error())
+		}
+	}
+	return patches, nil
+}
+
+// ApplyPatches applies the provided patch operations to this job, in order. The returned job update only
+// contains the fields changed by the patches, so that they can be updated without rewriting the rest of the job.
+// Files can only be changed until the job is submitted.
+// The following operations are supported:
+//   - replace /state with a state
+//   - add /files/- with an uploaded file
+//   - replace /files/{alias_name} with an uploaded file of the same alias name
+//   - remove /files/{alias_name}
+func (job *Job) ApplyPatches(patches []dprequest.Patch) (*Job, error) {
+	update := &Job{}
+
+	var files []UploadedFile
+	if job.UploadedFiles != nil {
+		files = append(files, *job.UploadedFiles...)
+	}
+	filesChanged := false
+
+	for _, patch := range patches {
+		tokens := strings.SplitN(strings.TrimPrefix(patch.Path, "/"), "/", 2)
+		field := tokens[0]
+		var item string
+		if len(tokens) == 2 {
+			item = jsonPointerUnescaper.Replace(tokens[1])
+		}
+
+		switch {
+		case field == "state" && len(tokens) == 1 && patch.Op == dprequest.OpReplace.String():
+			state, ok := patch.Value.(string)
+			if !ok || state == "" {
+				return nil, errs.ErrInvalidState
+			}
+			update.State = state
+
+		case field == "files" && item == "-" && patch.Op == dprequest.OpAdd.String():
+			file, err := patchUploadedFile(patch.Value)
+			if err != nil {
+				return nil, err
+			}
+			if uploadedFileIndex(files, file.AliasName) >= 0 {
+				return nil, errs.ErrorInvalidPatch(fmt.Sprintf("an uploaded file with alias name %q already exists", file.AliasName))
+			}
+			files = append(files, *file)
+			filesChanged = true
+
+		case field == "files" && item != "" && patch.Op == dprequest.OpReplace.String():
+			file, err := patchUploadedFile(patch.Value)
+			if err != nil {
+				return nil, err
+			}
+			i := uploadedFileIndex(files, item)
+			if i < 0 {
+				return nil, errs.ErrorInvalidPatch(fmt.Sprintf("no uploaded file with alias name %q", item))
+			}
+			if file.AliasName != item {
+				return nil, errs.ErrorInvalidPatch(fmt.Sprintf("the alias name of the uploaded file must be %q", item))
+			}
+			files[i] = *file
+			filesChanged = true
+
+		case field == "files" && item != "" && patch.Op == dprequest.OpRemove.String():
+			i := uploadedFileIndex(files, item)
+			if i < 0 {
+				return nil, errs.ErrorInvalidPatch(fmt.Sprintf("no uploaded file with alias name %q", item))
+			}
+			files = append(files[:i], files[i+1:]...)
+			filesChanged = true
+
+		default:
+			return nil, errs.ErrorInvalidPatch(fmt.Sprintf("op '%s' is not supported on path '%s'", patch.Op, patch.Path))
+		}
+	}
+
+	if filesChanged {
+		if job.State != CreatedState {
+			return nil, errs.ErrJobFilesLocked
+		}
+		if files == nil {
+			files = []UploadedFile{}
+		}
+		update.UploadedFiles = &files
+	}
+	return update, nil
+}
+
+// patchUploadedFile creates a valid uploaded file from the value of a patch operation
+func patchUploadedFile(value interface{}) (*UploadedFile, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, errs.ErrInvalidUploadedFileObject
+	}
+	var file UploadedFile
+	if err = json.Unmarshal(b, &file); err != nil {
+		return nil, errs.ErrInvalidUploadedFileObject
+	}
+	return &file, file.Validate()
+}
+
+// uploadedFileIndex returns the index of the uploaded file with the provided alias name, or -1 if there is none
+func uploadedFileIndex(files []UploadedFile, aliasName string) int {
+	for i, file := range files {
+		if file.AliasName == aliasName {
+			return i
+		}
+	}
+	return -1
+}
+
 // CreateInstance from a job ID and the provided recipe CodeLists
 // Neither job nor job.Links can be nil
 func CreateInstance(job *Job, datasetID, datasetURL string, codelists []recipe.CodeList) *dataset.NewInstance {
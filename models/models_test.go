@@ -2,14 +2,20 @@ package models
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/recipe"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/mocks"
+	dprequest "github.com/ONSdigital/dp-net/request"
 	. "github.com/smartystreets/goconvey/convey"
 	"go.mongodb.org/mongo-driver/bson"
+	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Regression test for new official golang driver which conforms to golang json spec on marshalling zero value structs.
@@ -137,6 +143,8 @@ func TestValidateState(t *testing.T) {
 			CompletedState,
 			CreatedState,
 			SubmittedState,
+			FailedState,
+			CancelledState,
 		}
 
 		for _, state := range listOfValidStates {
@@ -175,3 +183,666 @@ func TestValidateState(t *testing.T) {
 		})
 	})
 }
+
+func TestValidateTransition(t *testing.T) {
+	t.Parallel()
+	Convey("Given a list of allowed state transitions", t, func() {
+		allowed := map[string][]string{
+			CreatedState:   {CreatedState, SubmittedState, FailedState, CancelledState},
+			SubmittedState: {CompletedState, FailedState, CancelledState},
+			CancelledState: {CancelledState},
+		}
+
+		for from, targets := range allowed {
+			for _, to := range targets {
+				Convey("When validating the transition from "+from+" to "+to, func() {
+					job := &Job{State: to}
+					Convey("Then error should be nil", func() {
+						So(job.ValidateTransition(from), ShouldBeNil)
+					})
+				})
+			}
+		}
+	})
+
+	Convey("Given a list of illegal state transitions", t, func() {
+		illegal := map[string][]string{
+			SubmittedState: {CreatedState, SubmittedState},
+			CompletedState: {CreatedState, SubmittedState, CompletedState, FailedState, CancelledState},
+			FailedState:    {CreatedState, SubmittedState, CompletedState, FailedState, CancelledState},
+			CancelledState: {CreatedState, SubmittedState, CompletedState, FailedState},
+		}
+
+		for from, targets := range illegal {
+			for _, to := range targets {
+				Convey("When validating the transition from "+from+" to "+to, func() {
+					job := &Job{State: to}
+					Convey("Then an invalid state transition error should be returned", func() {
+						So(job.ValidateTransition(from), ShouldResemble, errs.ErrInvalidStateTransition)
+					})
+				})
+			}
+		}
+	})
+
+	Convey("Given job contains no state field", t, func() {
+		Convey("When validating the transition from a completed job", func() {
+			job := &Job{}
+			Convey("Then error should be nil", func() {
+				So(job.ValidateTransition(CompletedState), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestIsRepeatedTerminalState(t *testing.T) {
+	t.Parallel()
+	Convey("Given jobs in a terminal state", t, func() {
+		Convey("Then setting the state they are already in is a repeated terminal state", func() {
+			So((&Job{State: CompletedState}).IsRepeatedTerminalState(CompletedState), ShouldBeTrue)
+			So((&Job{State: FailedState}).IsRepeatedTerminalState(FailedState), ShouldBeTrue)
+		})
+		Convey("Then setting another state, or no state, is not", func() {
+			So((&Job{State: FailedState}).IsRepeatedTerminalState(CompletedState), ShouldBeFalse)
+			So((&Job{}).IsRepeatedTerminalState(CompletedState), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given jobs in a state that is not terminal", t, func() {
+		Convey("Then setting the state they are already in is not a repeated terminal state", func() {
+			So((&Job{State: CreatedState}).IsRepeatedTerminalState(CreatedState), ShouldBeFalse)
+			So((&Job{State: CancelledState}).IsRepeatedTerminalState(CancelledState), ShouldBeFalse)
+		})
+	})
+}
+
+func TestValidateUpdate(t *testing.T) {
+	t.Parallel()
+	Convey("Given a job update that only sets the state", t, func() {
+		job := &Job{State: SubmittedState}
+		Convey("Then the update is valid", func() {
+			So(job.ReadOnlyFields(), ShouldBeEmpty)
+			So(job.ValidateUpdate(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a job update that sets server-managed fields", t, func() {
+		job := &Job{
+			ID:        "123",
+			State:     SubmittedState,
+			Links:     &LinksMap{},
+			Processed: []ProcessedInstances{{ID: "1", ProcessedCount: 10}},
+		}
+		Convey("Then the update is rejected, listing the read-only fields", func() {
+			So(job.ReadOnlyFields(), ShouldResemble, []string{"id", "links", "processed_instances"})
+			err := job.ValidateUpdate()
+			So(errors.Is(err, errs.ErrReadOnlyJobFields), ShouldBeTrue)
+			So(err.Error(), ShouldEndWith, ": id, links, processed_instances")
+		})
+	})
+}
+
+func TestJobFilterValidate(t *testing.T) {
+	t.Parallel()
+	from := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	Convey("Given a filter with valid states and ordered date ranges", t, func() {
+		filter := &JobFilter{
+			States:          []string{CreatedState, SubmittedState},
+			LastUpdatedFrom: &from,
+			LastUpdatedTo:   &to,
+			CreatedFrom:     &from,
+			CreatedTo:       &from,
+		}
+		Convey("Then the filter is valid", func() {
+			So(filter.Validate(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a filter with an unknown state", t, func() {
+		filter := &JobFilter{States: []string{CreatedState, "started"}}
+		Convey("Then the filter is rejected", func() {
+			So(filter.Validate(), ShouldEqual, errs.ErrInvalidState)
+		})
+	})
+
+	Convey("Given a filter with a date range that ends before it starts", t, func() {
+		filter := &JobFilter{LastUpdatedFrom: &to, LastUpdatedTo: &from}
+		Convey("Then the filter is rejected", func() {
+			err := filter.Validate()
+			So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+			So(err.Error(), ShouldEndWith, "last_updated_to is before last_updated_from")
+		})
+	})
+}
+
+func TestParseJobSort(t *testing.T) {
+	t.Parallel()
+	Convey("Given a list of sortable fields with and without directions", t, func() {
+		sort, err := ParseJobSort("last_updated:desc,state,created:asc")
+		Convey("Then the fields are returned in order of precedence", func() {
+			So(err, ShouldBeNil)
+			So(sort, ShouldResemble, []JobSort{
+				{Field: "last_updated", Descending: true},
+				{Field: "state"},
+				{Field: "created"},
+			})
+		})
+	})
+
+	Convey("Given a field that jobs cannot be sorted by", t, func() {
+		_, err := ParseJobSort("state,processed_instances:desc")
+		Convey("Then the sort is rejected", func() {
+			So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+			So(err.Error(), ShouldEndWith, "cannot sort by 'processed_instances'")
+		})
+	})
+
+	Convey("Given a field sorted more than once", t, func() {
+		_, err := ParseJobSort("state:asc,state:desc")
+		Convey("Then the sort is rejected", func() {
+			So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given an invalid sort direction", t, func() {
+		_, err := ParseJobSort("last_updated:newest")
+		Convey("Then the sort is rejected", func() {
+			So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "invalid sort direction 'newest'")
+		})
+	})
+}
+
+func TestJobCursor(t *testing.T) {
+	t.Parallel()
+	Convey("Given an encoded job cursor", t, func() {
+		cursor := &JobCursor{LastUpdated: time.Date(2022, 5, 1, 10, 0, 0, 123000000, time.UTC), ID: "34534543543"}
+		encoded := cursor.Encode()
+		Convey("Then it is parsed back into the same cursor", func() {
+			parsed, err := ParseJobCursor(encoded)
+			So(err, ShouldBeNil)
+			So(parsed, ShouldResemble, cursor)
+		})
+	})
+
+	Convey("Given values that are not encoded job cursors", t, func() {
+		Convey("Then they are rejected", func() {
+			for _, value := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+				_, err := ParseJobCursor(value)
+				So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestParseJobFields(t *testing.T) {
+	t.Parallel()
+	Convey("Given a list of the json names of job fields", t, func() {
+		fields, err := ParseJobFields("state,files,processed_instances")
+		Convey("Then the fields are returned", func() {
+			So(err, ShouldBeNil)
+			So(fields, ShouldResemble, []string{"state", "files", "processed_instances"})
+		})
+	})
+
+	Convey("Given fields that are not returned to clients, or are not job fields", t, func() {
+		Convey("Then they are rejected", func() {
+			for _, value := range []string{"idempotency_key", "unique_timestamp", "RecipeID", "state,"} {
+				_, err := ParseJobFields(value)
+				So(errors.Is(err, errs.ErrInvalidQueryParameter), ShouldBeTrue)
+			}
+		})
+	})
+
+	Convey("Given the fields of a job", t, func() {
+		Convey("Then their json names are the same as their bson names, so that they can be projected", func() {
+			jobType := reflect.TypeOf(Job{})
+			for i := 0; i < jobType.NumField(); i++ {
+				jsonName := strings.Split(jobType.Field(i).Tag.Get("json"), ",")[0]
+				if jsonName == "-" {
+					continue
+				}
+				So(strings.Split(jobType.Field(i).Tag.Get("bson"), ",")[0], ShouldEqual, jsonName)
+			}
+		})
+	})
+}
+
+func TestCreateJobPatches(t *testing.T) {
+	t.Parallel()
+	Convey("Given a json-patch message with supported operations", t, func() {
+		reader := strings.NewReader(`[{"op":"replace","path":"/state","value":"submitted"},{"op":"remove","path":"/files/v4"}]`)
+		Convey("Then the patch operations are created", func() {
+			patches, err := CreateJobPatches(reader)
+			So(err, ShouldBeNil)
+			So(patches, ShouldHaveLength, 2)
+			So(patches[0].Op, ShouldEqual, "replace")
+			So(patches[1].Path, ShouldEqual, "/files/v4")
+		})
+	})
+
+	Convey("Given a json-patch message that cannot be parsed", t, func() {
+		Convey("Then a json parsing error is returned", func() {
+			_, err := CreateJobPatches(strings.NewReader(`{"op":"replace"}`))
+			So(err, ShouldEqual, errs.ErrFailedToParseJSONBody)
+		})
+	})
+
+	Convey("Given a json-patch message without any operations", t, func() {
+		Convey("Then an invalid patch error is returned", func() {
+			_, err := CreateJobPatches(strings.NewReader(`[]`))
+			So(errors.Is(err, errs.ErrInvalidPatch), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a json-patch message with an unsupported operation", t, func() {
+		Convey("Then an invalid patch error is returned", func() {
+			_, err := CreateJobPatches(strings.NewReader(`[{"op":"move","path":"/state","from":"/files"}]`))
+			So(errors.Is(err, errs.ErrInvalidPatch), ShouldBeTrue)
+		})
+	})
+}
+
+func TestApplyPatches(t *testing.T) {
+	t.Parallel()
+	Convey("Given a job with uploaded files", t, func() {
+		job := &Job{
+			ID:       "123",
+			RecipeID: "456",
+			State:    CreatedState,
+			UploadedFiles: &[]UploadedFile{
+				{AliasName: "v4", URL: "s3://bucket/v4.csv"},
+				{AliasName: "a/b", URL: "s3://bucket/ab.csv"},
+			},
+		}
+
+		Convey("When the state is replaced", func() {
+			update, err := job.ApplyPatches([]dprequest.Patch{{Op: "replace", Path: "/state", Value: SubmittedState}})
+
+			Convey("Then the update only contains the new state", func() {
+				So(err, ShouldBeNil)
+				So(update, ShouldResemble, &Job{State: SubmittedState})
+			})
+		})
+
+		Convey("When a file is added, another is replaced and another is removed", func() {
+			update, err := job.ApplyPatches([]dprequest.Patch{
+				{Op: "add", Path: "/files/-", Value: map[string]interface{}{"alias_name": "cpih", "url": "s3://bucket/cpih.csv"}},
+				{Op: "replace", Path: "/files/v4", Value: map[string]interface{}{"alias_name": "v4", "url": "s3://bucket/v4-new.csv"}},
+				{Op: "remove", Path: "/files/a~1b"},
+			})
+
+			Convey("Then the update only contains the patched files, and the job is unchanged", func() {
+				So(err, ShouldBeNil)
+				So(update.State, ShouldBeEmpty)
+				So(*update.UploadedFiles, ShouldResemble, []UploadedFile{
+					{AliasName: "v4", URL: "s3://bucket/v4-new.csv"},
+					{AliasName: "cpih", URL: "s3://bucket/cpih.csv"},
+				})
+				So(*job.UploadedFiles, ShouldHaveLength, 2)
+				So((*job.UploadedFiles)[0].URL, ShouldEqual, "s3://bucket/v4.csv")
+			})
+		})
+
+		Convey("When a file is added with an alias name that is already used", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{
+				{Op: "add", Path: "/files/-", Value: map[string]interface{}{"alias_name": "v4", "url": "s3://bucket/other.csv"}},
+			})
+
+			Convey("Then an invalid patch error is returned", func() {
+				So(errors.Is(err, errs.ErrInvalidPatch), ShouldBeTrue)
+			})
+		})
+
+		Convey("When an invalid file is added", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{{Op: "add", Path: "/files/-", Value: map[string]interface{}{"alias_name": "cpih"}}})
+
+			Convey("Then an invalid uploaded file error is returned", func() {
+				So(err, ShouldEqual, errs.ErrInvalidUploadedFileObject)
+			})
+		})
+
+		Convey("When a file that does not exist is removed", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{{Op: "remove", Path: "/files/cpih"}})
+
+			Convey("Then an invalid patch error is returned", func() {
+				So(errors.Is(err, errs.ErrInvalidPatch), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the state is replaced with a value that is not a string", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{{Op: "replace", Path: "/state", Value: 1}})
+
+			Convey("Then an invalid state error is returned", func() {
+				So(err, ShouldEqual, errs.ErrInvalidState)
+			})
+		})
+
+		Convey("When a field managed by the import api is patched", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{{Op: "replace", Path: "/links", Value: map[string]interface{}{}}})
+
+			Convey("Then an invalid patch error is returned", func() {
+				So(errors.Is(err, errs.ErrInvalidPatch), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a submitted job", t, func() {
+		job := &Job{State: SubmittedState, UploadedFiles: &[]UploadedFile{{AliasName: "v4", URL: "s3://bucket/v4.csv"}}}
+
+		Convey("When a file is removed", func() {
+			_, err := job.ApplyPatches([]dprequest.Patch{{Op: "remove", Path: "/files/v4"}})
+
+			Convey("Then the files cannot be changed", func() {
+				So(err, ShouldEqual, errs.ErrJobFilesLocked)
+			})
+		})
+	})
+}
+
+func TestPreviousStates(t *testing.T) {
+	t.Parallel()
+	Convey("When the previous states are requested for each state", t, func() {
+		Convey("Then the expected states are returned", func() {
+			So(PreviousStates(CreatedState), ShouldResemble, []string{CreatedState})
+			So(PreviousStates(SubmittedState), ShouldResemble, []string{CreatedState})
+			So(PreviousStates(CompletedState), ShouldResemble, []string{SubmittedState})
+			So(PreviousStates(FailedState), ShouldResemble, []string{CreatedState, SubmittedState})
+			So(PreviousStates(CancelledState), ShouldResemble, []string{CancelledState, CreatedState, SubmittedState})
+			So(PreviousStates("start"), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestIsProcessed(t *testing.T) {
+	t.Parallel()
+	Convey("Given a job with no processed instances", t, func() {
+		job := &Job{}
+		Convey("Then the job is not processed", func() {
+			So(IsProcessed(job.Processed), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a job with an instance that has not reached its required count", t, func() {
+		job := &Job{Processed: []ProcessedInstances{
+			{ID: "1", RequiredCount: 2, ProcessedCount: 2},
+			{ID: "2", RequiredCount: 3, ProcessedCount: 1},
+		}}
+		Convey("Then the job is not processed", func() {
+			So(IsProcessed(job.Processed), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given a job where every instance has reached its required count", t, func() {
+		job := &Job{Processed: []ProcessedInstances{
+			{ID: "1", RequiredCount: 2, ProcessedCount: 2},
+			{ID: "2", RequiredCount: 3, ProcessedCount: 3},
+		}}
+		Convey("Then the job is processed", func() {
+			So(IsProcessed(job.Processed), ShouldBeTrue)
+		})
+	})
+}
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+	Convey("Given a job with a unique timestamp", t, func() {
+		job := &Job{UniqueTimestamp: bsonprim.Timestamp{T: 1650000000, I: 3}}
+		Convey("Then its eTag is derived from the timestamp", func() {
+			So(job.ETag(), ShouldEqual, "1650000000-3")
+		})
+		Convey("Then the timestamp can be parsed back from the eTag", func() {
+			timestamp, err := ParseETag(job.ETag())
+			So(err, ShouldBeNil)
+			So(timestamp, ShouldResemble, job.UniqueTimestamp)
+		})
+	})
+
+	Convey("Given an eTag that was not derived from a unique timestamp", t, func() {
+		Convey("Then it cannot be parsed, as it cannot match any job", func() {
+			for _, eTag := range []string{"", "abc", "1-2-3", "a-1", "1-b"} {
+				_, err := ParseETag(eTag)
+				So(err, ShouldEqual, errs.ErrJobETagMismatch)
+			}
+		})
+	})
+}
+
+func TestUploadedFileForInstance(t *testing.T) {
+	t.Parallel()
+	Convey("Given import data with a single instance and a single unmapped file", t, func() {
+		data := &ImportData{
+			InstanceIDs:   []string{"1"},
+			UploadedFiles: &[]UploadedFile{{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"}},
+		}
+		Convey("Then the instance is imported from that file", func() {
+			file, err := data.UploadedFileForInstance("1")
+			So(err, ShouldBeNil)
+			So(file, ShouldResemble, &UploadedFile{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"})
+		})
+	})
+
+	Convey("Given import data with several instances mapped to files", t, func() {
+		data := &ImportData{
+			InstanceIDs: []string{"1", "2", "3"},
+			UploadedFiles: &[]UploadedFile{
+				{AliasName: "dataset1", URL: "s3://bucket/1.csv"},
+				{AliasName: "dataset2", URL: "s3://bucket/2.csv"},
+			},
+			InstanceFiles: []InstanceFile{
+				{InstanceID: "1", AliasName: "dataset1"},
+				{InstanceID: "3", AliasName: "dataset3"},
+			},
+		}
+		Convey("Then a mapped instance is imported from the file with its alias name", func() {
+			file, err := data.UploadedFileForInstance("1")
+			So(err, ShouldBeNil)
+			So(file, ShouldResemble, &UploadedFile{AliasName: "dataset1", URL: "s3://bucket/1.csv"})
+		})
+		Convey("Then an unmapped instance returns an error describing the missing mapping", func() {
+			file, err := data.UploadedFileForInstance("2")
+			So(file, ShouldBeNil)
+			So(err.Error(), ShouldEqual, "no file alias name is mapped to instance 2")
+		})
+		Convey("Then an instance mapped to a file that has not been uploaded returns an error describing the missing file", func() {
+			file, err := data.UploadedFileForInstance("3")
+			So(file, ShouldBeNil)
+			So(err.Error(), ShouldEqual, `no uploaded file with alias name "dataset3" for instance 3`)
+		})
+	})
+}
+
+// recipeWithFiles creates a recipe snapshot declaring the provided input files, and output instances for the provided datasets
+func recipeWithFiles(inputFiles []string, datasetIDs []string) *RecipeSnapshot {
+	snapshot := &RecipeSnapshot{ID: "123", Format: "v4", InputFiles: inputFiles}
+	for _, datasetID := range datasetIDs {
+		snapshot.OutputInstances = append(snapshot.OutputInstances, RecipeInstance{DatasetID: datasetID})
+	}
+	return snapshot
+}
+
+func TestNewRecipeSnapshot(t *testing.T) {
+	t.Parallel()
+	Convey("Given a recipe from the recipe API", t, func() {
+		var jobRecipe recipe.Recipe
+		err := json.Unmarshal([]byte(`{
+			"id": "123",
+			"format": "v4",
+			"files": [{"description": "CPIH v4"}],
+			"output_instances": [{"dataset_id": "cpih01", "title": "CPIH", "code_lists": [{"id": "mmm-yy", "href": "http://localhost:22400/code-lists/mmm-yy"}]}]
+		}`), &jobRecipe)
+		So(err, ShouldBeNil)
+
+		Convey("Then the snapshot records its format, the alias names of its input files, and its output instances", func() {
+			So(NewRecipeSnapshot(&jobRecipe), ShouldResemble, &RecipeSnapshot{
+				ID:         "123",
+				Format:     "v4",
+				InputFiles: []string{"CPIH v4"},
+				OutputInstances: []RecipeInstance{{
+					DatasetID: "cpih01",
+					CodeLists: []recipe.CodeList{{ID: "mmm-yy", HRef: "http://localhost:22400/code-lists/mmm-yy"}},
+				}},
+			})
+		})
+	})
+}
+
+func TestInstanceAliasName(t *testing.T) {
+	t.Parallel()
+	Convey("Given a recipe with a single input file", t, func() {
+		jobRecipe := recipeWithFiles([]string{"CPIH v4"}, []string{"cpih01", "cpih02"})
+		Convey("Then every output instance is imported from that file", func() {
+			So(jobRecipe.ValidateMapping(), ShouldBeNil)
+			So(instanceAliasName(jobRecipe, 0), ShouldEqual, "CPIH v4")
+			So(instanceAliasName(jobRecipe, 1), ShouldEqual, "CPIH v4")
+		})
+	})
+
+	Convey("Given a recipe with an input file per output instance", t, func() {
+		jobRecipe := recipeWithFiles([]string{"CPIH v4", "CPI v4"}, []string{"cpih01", "cpi01"})
+		Convey("Then each output instance is imported from the file at the same index", func() {
+			So(jobRecipe.ValidateMapping(), ShouldBeNil)
+			So(instanceAliasName(jobRecipe, 0), ShouldEqual, "CPIH v4")
+			So(instanceAliasName(jobRecipe, 1), ShouldEqual, "CPI v4")
+		})
+	})
+
+	Convey("Given a recipe without input files", t, func() {
+		jobRecipe := recipeWithFiles(nil, []string{"cpih01", "cpi01"})
+		Convey("Then the output instances are not imported from an uploaded file", func() {
+			So(jobRecipe.ValidateMapping(), ShouldBeNil)
+			So(instanceAliasName(jobRecipe, 0), ShouldBeEmpty)
+			So(instanceAliasName(jobRecipe, 1), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a recipe with several input files that do not match its output instances", t, func() {
+		jobRecipe := recipeWithFiles([]string{"CPIH v4", "CPI v4"}, []string{"cpih01", "cpi01", "cpi02"})
+		Convey("Then the recipe cannot be mapped, rather than mapping the instances to undeclared files", func() {
+			err := jobRecipe.ValidateMapping()
+			So(errors.Is(err, errs.ErrUnmappableRecipe), ShouldBeTrue)
+			So(err.Error(), ShouldEndWith, ": 2 input files for 3 output instances")
+
+			aliasName, err := jobRecipe.InstanceAliasName(2)
+			So(aliasName, ShouldBeEmpty)
+			So(errors.Is(err, errs.ErrUnmappableRecipe), ShouldBeTrue)
+		})
+	})
+}
+
+// instanceAliasName returns the alias name of the output instance at the provided index of a mappable recipe
+func instanceAliasName(jobRecipe *RecipeSnapshot, i int) string {
+	aliasName, err := jobRecipe.InstanceAliasName(i)
+	So(err, ShouldBeNil)
+	return aliasName
+}
+
+func TestCheckReadiness(t *testing.T) {
+	t.Parallel()
+	Convey("Given a recipe with two input files", t, func() {
+		jobRecipe := recipeWithFiles([]string{"CPIH v4", "CPI v4"}, []string{"cpih01", "cpi01"})
+
+		Convey("When the job has uploaded every expected file", func() {
+			job := &Job{UploadedFiles: &[]UploadedFile{
+				{AliasName: "CPI v4", URL: "s3://bucket/cpi.csv"},
+				{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"},
+			}}
+
+			Convey("Then the job is ready", func() {
+				readiness := job.CheckReadiness(jobRecipe)
+				So(readiness, ShouldResemble, &Readiness{Ready: true, MissingFiles: []string{}, UnexpectedFiles: []string{}})
+				So(readiness.ValidateReadiness(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the job is missing a file and has uploaded an unexpected one", func() {
+			job := &Job{
+				UploadedFiles: &[]UploadedFile{
+					{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"},
+					{AliasName: "wrong", URL: "s3://bucket/wrong.csv"},
+				},
+				InstanceFiles: []InstanceFile{{InstanceID: "1", AliasName: "CPIH v4"}, {InstanceID: "2", AliasName: "CPI v4"}},
+			}
+
+			Convey("Then the job is not ready, listing the missing and unexpected files", func() {
+				readiness := job.CheckReadiness(jobRecipe)
+				So(readiness.Ready, ShouldBeFalse)
+				So(readiness.MissingFiles, ShouldResemble, []string{"CPI v4"})
+				So(readiness.UnexpectedFiles, ShouldResemble, []string{"wrong"})
+
+				err := readiness.ValidateReadiness()
+				So(errors.Is(err, errs.ErrJobNotReady), ShouldBeTrue)
+				So(err.Error(), ShouldEndWith, "missing files: [CPI v4], unexpected files: [wrong]")
+			})
+		})
+
+		Convey("When an instance of the job is mapped to a file that has not been uploaded", func() {
+			job := &Job{
+				UploadedFiles: &[]UploadedFile{
+					{AliasName: "CPIH v4", URL: "s3://bucket/cpih.csv"},
+					{AliasName: "CPI v4", URL: "s3://bucket/cpi.csv"},
+				},
+				InstanceFiles: []InstanceFile{{InstanceID: "3", AliasName: "cpih02"}},
+			}
+
+			Convey("Then the file of the instance is missing", func() {
+				readiness := job.CheckReadiness(jobRecipe)
+				So(readiness.Ready, ShouldBeFalse)
+				So(readiness.MissingFiles, ShouldResemble, []string{"cpih02"})
+			})
+		})
+	})
+
+	Convey("Given a recipe without input files", t, func() {
+		jobRecipe := recipeWithFiles(nil, []string{"cantabular-dataset"})
+
+		Convey("Then a job without files is ready", func() {
+			So((&Job{}).CheckReadiness(jobRecipe).Ready, ShouldBeTrue)
+		})
+	})
+}
+
+func TestCreateProcessedDimension(t *testing.T) {
+	Convey("When a processed dimension message has no content, nil is returned without error", t, func() {
+		processed, err := CreateProcessedDimension(strings.NewReader(""))
+		So(err, ShouldBeNil)
+		So(processed, ShouldBeNil)
+	})
+
+	Convey("When a processed dimension message has an empty json, an error is returned", t, func() {
+		_, err := CreateProcessedDimension(strings.NewReader("{ }"))
+		So(err, ShouldEqual, errs.ErrInvalidProcessedDimension)
+	})
+
+	Convey("When a processed dimension message has an invalid json, an error is returned", t, func() {
+		_, err := CreateProcessedDimension(strings.NewReader("{"))
+		So(err, ShouldEqual, errs.ErrFailedToParseJSONBody)
+	})
+
+	Convey("When a processed dimension message has valid json, a processed dimension struct is returned", t, func() {
+		processed, err := CreateProcessedDimension(strings.NewReader(`{"dimension":"aggregate"}`))
+		So(err, ShouldBeNil)
+		So(processed.Dimension, ShouldEqual, "aggregate")
+	})
+}
+
+func TestCreateRetryOptions(t *testing.T) {
+	Convey("When a retry options message has no content, the default options are returned", t, func() {
+		options, err := CreateRetryOptions(strings.NewReader(""))
+		So(err, ShouldBeNil)
+		So(options, ShouldResemble, &RetryOptions{})
+	})
+
+	Convey("When a retry options message has an invalid json, an error is returned", t, func() {
+		_, err := CreateRetryOptions(strings.NewReader("{"))
+		So(err, ShouldEqual, errs.ErrFailedToParseJSONBody)
+	})
+
+	Convey("When a retry options message has valid json, a retry options struct is returned", t, func() {
+		options, err := CreateRetryOptions(strings.NewReader(`{"recreate_instances":true}`))
+		So(err, ShouldBeNil)
+		So(options.RecreateInstances, ShouldBeTrue)
+	})
+}
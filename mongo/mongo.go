@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
@@ -12,77 +13,170 @@ import (
 	"github.com/ONSdigital/dp-import-api/models"
 	"github.com/ONSdigital/log.go/v2/log"
 
-	mongolock "github.com/ONSdigital/dp-mongodb/v3/dplock"
 	mongohealth "github.com/ONSdigital/dp-mongodb/v3/health"
 	mongodriver "github.com/ONSdigital/dp-mongodb/v3/mongodb"
 
 	"go.mongodb.org/mongo-driver/bson"
 	bsonprim "go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 var _ datastore.DataStorer = (*Mongo)(nil)
 
+// outboxMessageRetention is how long sent and discarded outbox messages are kept for, before they expire
+const outboxMessageRetention = 7 * 24 * time.Hour
+
 type Mongo struct {
 	mongodriver.MongoDriverConfig
 
+	client       *mongo.Client
 	connection   *mongodriver.MongoConnection
 	healthClient *mongohealth.CheckMongoClient
-	lockClient   *mongolock.Lock
 }
 
 // NewDatastore creates a new mongodb.MongoConnection with the given configuration
 func NewDatastore(ctx context.Context, cfg config.MongoConfig) (m *Mongo, err error) {
 	m = &Mongo{MongoDriverConfig: cfg}
 
-	m.connection, err = mongodriver.Open(&m.MongoDriverConfig)
+	m.client, err = connect(ctx, &m.MongoDriverConfig)
 	if err != nil {
 		return nil, err
 	}
+	m.connection = mongodriver.NewMongoConnection(m.client, m.Database)
 
 	databaseCollectionBuilder := map[mongohealth.Database][]mongohealth.Collection{
 		mongohealth.Database(m.Database): {
 			mongohealth.Collection(m.ActualCollectionName(config.ImportsCollection)),
-			mongohealth.Collection(m.ActualCollectionName(config.ImportsLockCollection)),
+			mongohealth.Collection(m.ActualCollectionName(config.OutboxCollection)),
 		},
 	}
 	m.healthClient = mongohealth.NewClientWithCollections(m.connection, databaseCollectionBuilder)
-	m.lockClient = mongolock.New(ctx, m.connection, config.ImportsCollection)
+
+	if err = m.createIndexes(ctx); err != nil {
+		return nil, err
+	}
 
 	return m, nil
 }
 
-// AcquireInstanceLock tries to lock the provided jobID.
-// If the job is already locked, this function will block until it's released,
-// at which point we acquire the lock and return.
-// Note: the lock is currently only used to update processed_instances
-func (m *Mongo) AcquireInstanceLock(ctx context.Context, jobID string) (lockID string, err error) {
-	return m.lockClient.Acquire(ctx, jobID)
-}
+// connect creates a client connected to the cluster with the given configuration, with the same options as
+// mongodriver.Open. The client is kept, so that the operations the connection does not provide, such as
+// find and update, can be run with the driver.
+func connect(ctx context.Context, cfg *mongodriver.MongoDriverConfig) (*mongo.Client, error) {
+	tlsConfig, err := cfg.GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 
-// UnlockInstance releases an exclusive mongoDB lock for the provided lockId (if it exists)
-// Note: the lock is currently only used to update processed_instances
-func (m *Mongo) UnlockInstance(ctx context.Context, lockID string) {
-	m.lockClient.Unlock(ctx, lockID)
+	connectionURI, err := cfg.GetConnectionURI()
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := options.Client().
+		ApplyURI(connectionURI).
+		SetTLSConfig(tlsConfig).
+		SetRetryWrites(false)
+
+	if cfg.IsStrongReadConcernEnabled {
+		clientOptions.SetReadPreference(readpref.Primary()).SetReadConcern(readconcern.Majority())
+	} else {
+		clientOptions.SetReadPreference(readpref.SecondaryPreferred())
+	}
+
+	if cfg.IsWriteConcernMajorityEnabled {
+		clientOptions.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+	} else {
+		clientOptions.SetWriteConcern(writeconcern.New(writeconcern.W(1)))
+	}
+
+	connectionCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(connectionCtx, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	// force a connection to verify the connection string
+	if err = client.Ping(connectionCtx, nil); err != nil {
+		return nil, fmt.Errorf("failed to ping cluster: %w", err)
+	}
+
+	return client, nil
 }
 
-// GetJobs retrieves all import documents matching filters
-func (m *Mongo) GetJobs(ctx context.Context, filters []string, offset int, limit int) (*models.JobResults, error) {
-	stateFilter := bson.M{}
-	if len(filters) > 0 {
-		stateFilter["state"] = bson.M{"$in": filters}
+// createIndexes creates the indexes of the imports and outbox collections, if they do not exist yet.
+// Idempotency keys are unique, so that concurrent requests with the same key cannot create two jobs.
+// The last updated indexes back the list of jobs sorted by last updated, in either direction, with or without a state filter,
+// and the pages of jobs listed after a cursor.
+// The outbox indexes back the updates of a message and the regular queries of the pending messages, and remove the
+// messages that have expired.
+func (m *Mongo) createIndexes(ctx context.Context) error {
+	err := m.connection.RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: m.ActualCollectionName(config.ImportsCollection)},
+		{Key: "indexes", Value: bson.A{
+			bson.M{
+				"key":                     bson.M{"idempotency_key": 1},
+				"name":                    "idempotency_key_unique",
+				"unique":                  true,
+				"partialFilterExpression": bson.M{"idempotency_key": bson.M{"$exists": true}},
+			},
+			bson.M{
+				"key":  bson.D{{Key: "last_updated", Value: 1}, {Key: "_id", Value: 1}},
+				"name": "last_updated",
+			},
+			bson.M{
+				"key":  bson.D{{Key: "state", Value: 1}, {Key: "last_updated", Value: 1}, {Key: "_id", Value: 1}},
+				"name": "state_last_updated",
+			},
+			bson.M{
+				"key":  bson.D{{Key: "last_updated", Value: 1}, {Key: "id", Value: 1}},
+				"name": "last_updated_cursor",
+			},
+		}},
+	})
+	if err != nil {
+		return err
 	}
 
-	var jobItems []*models.Job
-	totalCount, err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Find(ctx, stateFilter, &jobItems,
-		mongodriver.Sort(bson.M{"_id": 1}), mongodriver.Offset(offset), mongodriver.Limit(limit))
+	return m.connection.RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: m.ActualCollectionName(config.OutboxCollection)},
+		{Key: "indexes", Value: bson.A{
+			bson.M{
+				"key":    bson.M{"id": 1},
+				"name":   "id_unique",
+				"unique": true,
+			},
+			bson.M{
+				"key":  bson.D{{Key: "state", Value: 1}, {Key: "created_at", Value: 1}},
+				"name": "state_created_at",
+			},
+			bson.M{
+				"key":                bson.M{"expires_at": 1},
+				"name":               "expires_at_ttl",
+				"expireAfterSeconds": 0,
+			},
+		}},
+	})
+}
+
+// GetJobs retrieves all import documents matching filters, in the provided sort order, with only the provided fields,
+// or every field if none are provided. The results are empty, rather than an error, if no documents match.
+func (m *Mongo) GetJobs(ctx context.Context, filter *models.JobFilter, sort []models.JobSort, fields []string, offset int, limit int) (*models.JobResults, error) {
+	selector := jobsSelector(filter)
+
+	jobItems := []*models.Job{}
+	totalCount, err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Find(ctx, selector, &jobItems,
+		mongodriver.Sort(jobsSort(sort)), mongodriver.Offset(offset), mongodriver.Limit(limit), jobsProjection(fields))
 	if err != nil {
 		log.Error(ctx, "error finding items", err)
 		return nil, err
 	}
-	if totalCount < 1 {
-		return nil, apierrors.ErrJobNotFound
-	}
-
 	return &models.JobResults{
 		Items:      jobItems,
 		Count:      len(jobItems),
@@ -92,10 +186,134 @@ func (m *Mongo) GetJobs(ctx context.Context, filters []string, offset int, limit
 	}, nil
 }
 
+// GetJobsAfter retrieves the import documents matching filters, in last updated order, that come after the provided
+// cursor, with only the provided fields, or every field if none are provided. The first page is retrieved if the
+// cursor is nil. The results hold the cursor of the following page, if there are more documents to retrieve, and
+// they are empty if no documents match.
+func (m *Mongo) GetJobsAfter(ctx context.Context, filter *models.JobFilter, cursor *models.JobCursor, fields []string, limit int) (*models.JobResults, error) {
+	collection := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection))
+	selector := jobsSelector(filter)
+
+	totalCount, err := collection.Count(ctx, selector)
+	if err != nil {
+		log.Error(ctx, "error counting items", err)
+		return nil, err
+	}
+	if cursor != nil {
+		selector = bson.M{"$and": bson.A{selector, bson.M{"$or": bson.A{
+			bson.M{"last_updated": bson.M{"$gt": cursor.LastUpdated}},
+			bson.M{"last_updated": cursor.LastUpdated, "id": bson.M{"$gt": cursor.ID}},
+		}}}}
+	}
+
+	jobItems := []*models.Job{}
+	remainingCount, err := collection.Find(ctx, selector, &jobItems,
+		mongodriver.Sort(bson.D{{Key: "last_updated", Value: 1}, {Key: "id", Value: 1}}), mongodriver.Limit(limit),
+		jobsProjection(fields, "last_updated"))
+	if err != nil {
+		log.Error(ctx, "error finding items", err)
+		return nil, err
+	}
+
+	results := &models.JobResults{
+		Items:      jobItems,
+		Count:      len(jobItems),
+		TotalCount: totalCount,
+		Limit:      limit,
+	}
+	if len(jobItems) > 0 && remainingCount > len(jobItems) {
+		last := jobItems[len(jobItems)-1]
+		results.NextCursor = (&models.JobCursor{LastUpdated: last.LastUpdated, ID: last.ID}).Encode()
+	}
+
+	return results, nil
+}
+
+// jobsSelector builds the query matching the jobs selected by the provided filter. The import documents do not
+// record when they were created, so the created range is matched against the timestamp of their object ID.
+func jobsSelector(filter *models.JobFilter) bson.M {
+	selector := bson.M{}
+	if filter == nil {
+		return selector
+	}
+
+	inFilter := func(field string, values []string) {
+		if len(values) > 0 {
+			selector[field] = bson.M{"$in": values}
+		}
+	}
+	inFilter("state", filter.States)
+	inFilter("recipe", filter.RecipeIDs)
+	inFilter("links.instances.id", filter.InstanceIDs)
+	inFilter("files.alias_name", filter.FileAliasNames)
+	inFilter("files.url", filter.FileURLs)
+
+	// the datasets of the instances are only recorded with the jobs created since instances were mapped to them,
+	// older jobs only link to their instances, so they are not matched
+	if len(filter.DatasetIDs) > 0 {
+		selector["$or"] = bson.A{
+			bson.M{"instance_files.dataset_id": bson.M{"$in": filter.DatasetIDs}},
+			bson.M{"recipe_snapshot.output_instances.dataset_id": bson.M{"$in": filter.DatasetIDs}},
+		}
+	}
+
+	lastUpdated := bson.M{}
+	if filter.LastUpdatedFrom != nil {
+		lastUpdated["$gte"] = *filter.LastUpdatedFrom
+	}
+	if filter.LastUpdatedTo != nil {
+		lastUpdated["$lte"] = *filter.LastUpdatedTo
+	}
+	if len(lastUpdated) > 0 {
+		selector["last_updated"] = lastUpdated
+	}
+
+	// object ID timestamps are in whole seconds, so the end of the range is extended to the following second
+	created := bson.M{}
+	if filter.CreatedFrom != nil {
+		created["$gte"] = bsonprim.NewObjectIDFromTimestamp(filter.CreatedFrom.Truncate(time.Second))
+	}
+	if filter.CreatedTo != nil {
+		created["$lt"] = bsonprim.NewObjectIDFromTimestamp(filter.CreatedTo.Truncate(time.Second).Add(time.Second))
+	}
+	if len(created) > 0 {
+		selector["_id"] = created
+	}
+
+	return selector
+}
+
+// jobsSort builds the sort order of a list of jobs. Jobs are finally sorted in the order they were created, in the
+// direction of the last sort field, so that the order of jobs with equal sort fields is stable between pages.
+func jobsSort(sort []models.JobSort) bson.D {
+	order := bson.D{}
+	direction := 1
+	for _, field := range sort {
+		key := field.Field
+		if key == "created" {
+			key = "_id"
+		}
+		direction = 1
+		if field.Descending {
+			direction = -1
+		}
+		order = append(order, bson.E{Key: key, Value: direction})
+		if key == "_id" {
+			return order
+		}
+	}
+	return append(order, bson.E{Key: "_id", Value: direction})
+}
+
 // GetJob retrieves a single import job
 func (m *Mongo) GetJob(ctx context.Context, id string) (*models.Job, error) {
+	return m.GetJobFields(ctx, id, nil)
+}
+
+// GetJobFields retrieves a single import job with only the provided fields, or every field if none are provided
+func (m *Mongo) GetJobFields(ctx context.Context, id string, fields []string) (*models.Job, error) {
 	var job models.Job
-	if err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).FindOne(ctx, bson.M{"id": id}, &job); err != nil {
+	if err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).FindOne(ctx, bson.M{"id": id}, &job, jobsProjection(fields)); err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return nil, apierrors.ErrJobNotFound
 		}
@@ -105,22 +323,121 @@ func (m *Mongo) GetJob(ctx context.Context, id string) (*models.Job, error) {
 	return &job, nil
 }
 
-// AddJob adds an ImportJob document - the ID is assumed to be set
+// jobsProjection builds the find option projecting the provided job fields, along with any required fields. The json
+// names of the job fields are the same as the names of the document fields. The id, and the unique timestamp the
+// eTag is derived from, are always returned, and every field is returned if no fields are provided.
+func jobsProjection(fields []string, requiredFields ...string) mongodriver.FindOption {
+	if len(fields) == 0 {
+		return mongodriver.Projection(nil)
+	}
+
+	projection := bson.M{"id": 1, "unique_timestamp": 1}
+	for _, field := range fields {
+		projection[field] = 1
+	}
+	for _, field := range requiredFields {
+		projection[field] = 1
+	}
+	return mongodriver.Projection(projection)
+}
+
+// GetJobByIdempotencyKey retrieves the import job created with the provided idempotency key, while the key has not expired
+func (m *Mongo) GetJobByIdempotencyKey(ctx context.Context, key string) (*models.Job, error) {
+	selector := bson.M{
+		"idempotency_key":        key,
+		"idempotency_key_expiry": bson.M{"$gt": time.Now().UTC()},
+	}
+
+	var job models.Job
+	if err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).FindOne(ctx, selector, &job); err != nil {
+		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
+			return nil, apierrors.ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// AddJob adds an ImportJob document - the ID is assumed to be set.
+// If the job has an idempotency key, it is released from any job whose key has expired, and
+// ErrDuplicateIdempotencyKey is returned if another job has already been created with it.
 func (m *Mongo) AddJob(ctx context.Context, job *models.Job) (*models.Job, error) {
 	currentTime := time.Now().UTC()
 	job.LastUpdated = currentTime
 	job.UniqueTimestamp = bsonprim.Timestamp{T: uint32(time.Now().Unix())}
 
-	if _, err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Insert(ctx, job); err != nil {
+	collection := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection))
+	if job.IdempotencyKey != "" {
+		expired := bson.M{"idempotency_key": job.IdempotencyKey, "idempotency_key_expiry": bson.M{"$lte": currentTime}}
+		if _, err := collection.UpdateMany(ctx, expired, bson.M{"$unset": bson.M{"idempotency_key": "", "idempotency_key_expiry": ""}}); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := collection.Insert(ctx, job); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, apierrors.ErrDuplicateIdempotencyKey
+		}
 		return nil, err
 	}
 
 	return m.GetJob(ctx, job.ID)
 }
 
-// updateByID is a helper function to update a job given an update operator
-func (m *Mongo) updateByID(ctx context.Context, id string, update bson.M) (err error) {
-	if _, err = m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Must().Update(ctx, bson.M{"id": id}, update); err != nil {
+// updateByID is a helper function to update a job given an update operator.
+// Unless any eTag is allowed, the job is only updated if its eTag matches the provided one.
+func (m *Mongo) updateByID(ctx context.Context, id, eTag string, update bson.M) (err error) {
+	selector, err := eTagSelector(bson.M{"id": id}, eTag)
+	if err != nil {
+		return err
+	}
+
+	err = m.update(ctx, selector, update)
+	if errors.Is(err, apierrors.ErrJobNotFound) {
+		return m.unmatchedUpdateError(ctx, id, eTag, err)
+	}
+	return err
+}
+
+// eTagSelector adds the unique timestamp of the provided eTag to the provided selector, unless any eTag is allowed
+func eTagSelector(selector bson.M, eTag string) (bson.M, error) {
+	if eTag == "" || eTag == models.AnyETag {
+		return selector, nil
+	}
+
+	timestamp, err := models.ParseETag(eTag)
+	if err != nil {
+		return nil, err
+	}
+
+	// jobs that have not been updated since unique timestamps were introduced do not have one
+	if timestamp.IsZero() {
+		selector["unique_timestamp"] = bson.M{"$exists": false}
+		return selector, nil
+	}
+
+	selector["unique_timestamp"] = timestamp
+	return selector, nil
+}
+
+// unmatchedUpdateError returns the error for a conditional update of the provided job that did not match it:
+// ErrJobNotFound if the job does not exist, ErrJobETagMismatch if its eTag does not match, or the provided error otherwise
+func (m *Mongo) unmatchedUpdateError(ctx context.Context, id, eTag string, unmatchedErr error) error {
+	job, err := m.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if eTag != "" && eTag != models.AnyETag && job.ETag() != eTag {
+		return apierrors.ErrJobETagMismatch
+	}
+	return unmatchedErr
+}
+
+// update is a helper function to update the job matching the provided selector given an update operator
+func (m *Mongo) update(ctx context.Context, selector bson.M, update bson.M) (err error) {
+	if _, err = m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Must().Update(ctx, selector, update); err != nil {
 		if errors.Is(err, mongodriver.ErrNoDocumentFound) {
 			return apierrors.ErrJobNotFound
 		}
@@ -130,15 +447,140 @@ func (m *Mongo) updateByID(ctx context.Context, id string, update bson.M) (err e
 	return nil
 }
 
-// AddUploadedFile adds an UploadedFile to an import job
-func (m *Mongo) AddUploadedFile(ctx context.Context, id string, file *models.UploadedFile) error {
-	return m.updateByID(ctx, id, bson.M{
-		"$addToSet": bson.M{
-			"files": bson.M{
-				"alias_name": file.AliasName,
-				"url":        file.URL,
+// maxFileUpdateAttempts is the number of times a file is attempted to be added to a job, when
+// the files of the job are changed concurrently between its replace and add updates
+const maxFileUpdateAttempts = 3
+
+// filesSelector selects the job with the provided id while its files can be changed, i.e. before it is submitted.
+// Unless any eTag is allowed, the job is only selected if its eTag matches the provided one.
+func filesSelector(id, eTag string) (bson.M, error) {
+	return eTagSelector(bson.M{"id": id, "state": models.CreatedState}, eTag)
+}
+
+// AddUploadedFile adds an UploadedFile to an import job, replacing any file with the same alias name.
+// Files can only be changed until the job is submitted, and unless any eTag is allowed, only if the job eTag matches the provided one.
+func (m *Mongo) AddUploadedFile(ctx context.Context, id string, file *models.UploadedFile, eTag string) error {
+	fileDoc := bson.M{
+		"alias_name": file.AliasName,
+		"url":        file.URL,
+	}
+	currentDate := bson.M{
+		"last_updated": true,
+		"unique_timestamp": bson.M{
+			"$type": "timestamp",
+		},
+	}
+
+	for attempt := 0; attempt < maxFileUpdateAttempts; attempt++ {
+		// replace the file with the same alias name, if there is one
+		selector, err := filesSelector(id, eTag)
+		if err != nil {
+			return err
+		}
+		selector["files.alias_name"] = file.AliasName
+
+		matched, err := m.updateMatched(ctx, selector, bson.M{
+			"$set":         bson.M{"files.$": fileDoc},
+			"$currentDate": currentDate,
+		})
+		if err != nil || matched {
+			return err
+		}
+
+		// otherwise add it
+		selector, err = filesSelector(id, eTag)
+		if err != nil {
+			return err
+		}
+		selector["files.alias_name"] = bson.M{"$ne": file.AliasName}
+
+		matched, err = m.updateMatched(ctx, selector, bson.M{
+			"$push":        bson.M{"files": fileDoc},
+			"$currentDate": currentDate,
+		})
+		if err != nil || matched {
+			return err
+		}
+
+		if err = m.unmatchedFilesUpdateError(ctx, id, eTag); err != nil {
+			return err
+		}
+		// a file with the same alias name was added or removed in the meantime, so try again
+	}
+
+	return apierrors.ErrJobETagMismatch
+}
+
+// DeleteUploadedFile removes the UploadedFile with the provided alias name from an import job.
+// Files can only be changed until the job is submitted, and unless any eTag is allowed, only if the job eTag matches the provided one.
+func (m *Mongo) DeleteUploadedFile(ctx context.Context, id, aliasName, eTag string) error {
+	selector, err := filesSelector(id, eTag)
+	if err != nil {
+		return err
+	}
+	selector["files.alias_name"] = aliasName
+
+	matched, err := m.updateMatched(ctx, selector, bson.M{
+		"$pull": bson.M{"files": bson.M{"alias_name": aliasName}},
+		"$currentDate": bson.M{
+			"last_updated": true,
+			"unique_timestamp": bson.M{
+				"$type": "timestamp",
 			},
 		},
+	})
+	if err != nil || matched {
+		return err
+	}
+
+	if err = m.unmatchedFilesUpdateError(ctx, id, eTag); err != nil {
+		return err
+	}
+	return apierrors.ErrUploadedFileNotFound
+}
+
+// unmatchedFilesUpdateError returns the error for an update of the files of the provided job that did not match it:
+// ErrJobNotFound if the job does not exist, ErrJobETagMismatch if its eTag does not match, ErrJobFilesLocked
+// if it has been submitted, or nil if the job could have been matched, i.e. its files did not match instead
+func (m *Mongo) unmatchedFilesUpdateError(ctx context.Context, id, eTag string) error {
+	job, err := m.GetJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if eTag != "" && eTag != models.AnyETag && job.ETag() != eTag {
+		return apierrors.ErrJobETagMismatch
+	}
+	if job.State != models.CreatedState {
+		return apierrors.ErrJobFilesLocked
+	}
+	return nil
+}
+
+// updateMatched is a helper function to update the job matching the provided selector given an update operator,
+// returning false if no job was matched
+func (m *Mongo) updateMatched(ctx context.Context, selector bson.M, update bson.M) (bool, error) {
+	result, err := m.connection.Collection(m.ActualCollectionName(config.ImportsCollection)).Update(ctx, selector, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// UpdateJob adds or overides an existing import job.
+// If the state is being changed, the job is only updated if its stored state can transition to the new one,
+// so that concurrent requests cannot apply the same transition twice.
+// Unless any eTag is allowed, the job is only updated if its eTag matches the provided one.
+func (m *Mongo) UpdateJob(ctx context.Context, id string, job *models.Job, eTag string) (err error) {
+	selector, err := eTagSelector(bson.M{"id": id}, eTag)
+	if err != nil {
+		return err
+	}
+	if job.State != "" {
+		selector["state"] = bson.M{"$in": models.PreviousStates(job.State)}
+	}
+
+	err = m.update(ctx, selector, bson.M{
+		"$set": job,
 		"$currentDate": bson.M{
 			"last_updated": true,
 			"unique_timestamp": bson.M{
@@ -146,11 +588,19 @@ func (m *Mongo) AddUploadedFile(ctx context.Context, id string, file *models.Upl
 			},
 		},
 	})
+	if errors.Is(err, apierrors.ErrJobNotFound) {
+		if job.State != "" {
+			err = apierrors.ErrInvalidStateTransition
+		}
+		return m.unmatchedUpdateError(ctx, id, eTag, err)
+	}
+	return err
 }
 
-// UpdateJob adds or overides an existing import job
-func (m *Mongo) UpdateJob(ctx context.Context, id string, job *models.Job) (err error) {
-	return m.updateByID(ctx, id, bson.M{
+// RetryJob overides an existing import job to start a new attempt of it.
+// The job is only updated while it is failed, so that concurrent requests cannot retry it twice.
+func (m *Mongo) RetryJob(ctx context.Context, id string, job *models.Job) (err error) {
+	err = m.update(ctx, bson.M{"id": id, "state": models.FailedState}, bson.M{
 		"$set": job,
 		"$currentDate": bson.M{
 			"last_updated": true,
@@ -159,19 +609,169 @@ func (m *Mongo) UpdateJob(ctx context.Context, id string, job *models.Job) (err
 			},
 		},
 	})
+	if errors.Is(err, apierrors.ErrJobNotFound) {
+		return apierrors.ErrJobNotFailed
+	}
+	return err
 }
 
-// UpdateProcessedInstance overides the processed instances for an existing import job
-func (m *Mongo) UpdateProcessedInstance(ctx context.Context, id string, procInstances []models.ProcessedInstances) (err error) {
-	return m.updateByID(ctx, id, bson.M{
-		"$set": bson.M{"processed_instances": procInstances},
+// IncreaseProcessedInstance atomically increases the processed count for the provided instance of an import job,
+// and returns the processed instances as updated by this increase. If a codelist ID or dimension name is provided,
+// it is added to the processed dimensions of the instance and the count is only increased if it had not been processed
+// already, in which case the current processed instances are returned. Cancelled jobs are not updated, and unless
+// any eTag is allowed, the job is only updated if its eTag matches the provided one.
+func (m *Mongo) IncreaseProcessedInstance(ctx context.Context, jobID, instanceID, dimension, eTag string) ([]models.ProcessedInstances, error) {
+	instanceFilter := bson.M{"instance.id": instanceID}
+	processedInstance := bson.M{"id": instanceID}
+	update := bson.M{
+		"$inc": bson.M{"processed_instances.$[instance].processed_count": 1},
 		"$currentDate": bson.M{
 			"last_updated": true,
 			"unique_timestamp": bson.M{
 				"$type": "timestamp",
 			},
 		},
+	}
+
+	if dimension != "" {
+		instanceFilter["instance.processed_dimensions"] = bson.M{"$ne": dimension}
+		processedInstance["processed_dimensions"] = bson.M{"$ne": dimension}
+		update["$push"] = bson.M{"processed_instances.$[instance].processed_dimensions": dimension}
+	}
+
+	selector, err := eTagSelector(bson.M{
+		"id":                  jobID,
+		"state":               bson.M{"$ne": models.CancelledState},
+		"processed_instances": bson.M{"$elemMatch": processedInstance},
+	}, eTag)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetArrayFilters(options.ArrayFilters{Filters: []interface{}{instanceFilter}}).
+		SetProjection(bson.M{"processed_instances": 1}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	err = m.client.Database(m.Database).Collection(m.ActualCollectionName(config.ImportsCollection)).
+		FindOneAndUpdate(ctx, selector, update, opts).Decode(&job)
+	if err == nil {
+		return job.Processed, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	return m.unmatchedProcessedInstance(ctx, jobID, instanceID, eTag)
+}
+
+// unmatchedProcessedInstance returns the result of an increase of the processed count for the provided instance
+// of a job that did not match it: ErrJobNotFound if the job does not exist, ErrJobETagMismatch if its eTag does not match,
+// ErrJobCancelled if it has been cancelled, ErrInvalidInstanceID if the instance is not one of the job.
+// Otherwise the dimension had already been processed, and the current processed instances are returned.
+func (m *Mongo) unmatchedProcessedInstance(ctx context.Context, jobID, instanceID, eTag string) ([]models.ProcessedInstances, error) {
+	job, err := m.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if eTag != "" && eTag != models.AnyETag && job.ETag() != eTag {
+		return nil, apierrors.ErrJobETagMismatch
+	}
+
+	if job.State == models.CancelledState {
+		return nil, apierrors.ErrJobCancelled
+	}
+
+	for _, instance := range job.Processed {
+		if instance.ID == instanceID {
+			return job.Processed, nil
+		}
+	}
+
+	return nil, apierrors.ErrInvalidInstanceID
+}
+
+// AddOutboxMessage adds an OutboxMessage document - the ID is assumed to be set
+func (m *Mongo) AddOutboxMessage(ctx context.Context, message *models.OutboxMessage) error {
+	message.CreatedAt = time.Now().UTC()
+	message.LastUpdated = message.CreatedAt
+
+	_, err := m.connection.Collection(m.ActualCollectionName(config.OutboxCollection)).Insert(ctx, message)
+	return err
+}
+
+// GetPendingOutboxMessages retrieves the oldest pending outbox messages that were created before the provided time,
+// along with the messages that were claimed before the provided time but have not been sent, e.g. because the
+// process sending them stopped
+func (m *Mongo) GetPendingOutboxMessages(ctx context.Context, createdBefore, claimedBefore time.Time, limit int) ([]*models.OutboxMessage, error) {
+	selector := bson.M{"$or": bson.A{
+		bson.M{"state": models.OutboxPendingState, "created_at": bson.M{"$lt": createdBefore}},
+		bson.M{"state": models.OutboxSendingState, "claimed_at": bson.M{"$lt": claimedBefore}},
+	}}
+
+	messages := []*models.OutboxMessage{}
+	if _, err := m.connection.Collection(m.ActualCollectionName(config.OutboxCollection)).Find(ctx, selector, &messages,
+		mongodriver.Sort(bson.M{"created_at": 1}), mongodriver.Limit(limit)); err != nil {
+		log.Error(ctx, "error finding outbox messages", err)
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// ClaimOutboxMessage moves a pending outbox message to the sending state, so that no other process sends it.
+// A message claimed before the provided time that has not been sent can be claimed again. It returns false if
+// the message cannot be claimed, because it has been claimed by another process or it is no longer pending.
+func (m *Mongo) ClaimOutboxMessage(ctx context.Context, id string, claimedBefore time.Time) (bool, error) {
+	selector := bson.M{"id": id, "$or": bson.A{
+		bson.M{"state": models.OutboxPendingState},
+		bson.M{"state": models.OutboxSendingState, "claimed_at": bson.M{"$lt": claimedBefore}},
+	}}
+
+	result, err := m.connection.Collection(m.ActualCollectionName(config.OutboxCollection)).Update(ctx, selector, bson.M{
+		"$set": bson.M{"state": models.OutboxSendingState},
+		"$currentDate": bson.M{
+			"claimed_at":   true,
+			"last_updated": true,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// UpdateOutboxMessageState sets the state of an outbox message. Sent and discarded messages expire after
+// outboxMessageRetention.
+func (m *Mongo) UpdateOutboxMessageState(ctx context.Context, id, state string) error {
+	set := bson.M{"state": state}
+	if state == models.OutboxSentState || state == models.OutboxDiscardedState {
+		set["expires_at"] = time.Now().UTC().Add(outboxMessageRetention)
+	}
+
+	_, err := m.connection.Collection(m.ActualCollectionName(config.OutboxCollection)).Must().Update(ctx, bson.M{"id": id}, bson.M{
+		"$set": set,
+		"$currentDate": bson.M{
+			"last_updated": true,
+		},
+	})
+	return err
+}
+
+// IncreaseOutboxMessageAttempts increases the number of failed attempts to send an outbox message, and releases
+// the claim on the message, so that it is pending again
+func (m *Mongo) IncreaseOutboxMessageAttempts(ctx context.Context, id string) error {
+	_, err := m.connection.Collection(m.ActualCollectionName(config.OutboxCollection)).Must().Update(ctx, bson.M{"id": id}, bson.M{
+		"$inc":   bson.M{"attempts": 1},
+		"$set":   bson.M{"state": models.OutboxPendingState},
+		"$unset": bson.M{"claimed_at": ""},
+		"$currentDate": bson.M{
+			"last_updated": true,
+		},
 	})
+	return err
 }
 
 // Checker is called by the healthcheck library to check the health state of this mongoDB instance
@@ -3,21 +3,18 @@ package mongo
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ONSdigital/dp-healthcheck/healthcheck"
 	errs "github.com/ONSdigital/dp-import-api/apierrors"
 	"github.com/ONSdigital/dp-import-api/models"
 )
 
-const testLockID = "testLockID"
-
 var InternalError = errors.New("DataStore internal error")
 
 type DataStorer struct {
 	NotFound      bool
 	InternalError bool
-	IsLocked      bool
-	HasBeenLocked bool
 }
 
 // CreatedJob represents a job returned by AddJob
@@ -30,13 +27,30 @@ func (ds *DataStorer) AddJob(_ context.Context, _ *models.Job) (*models.Job, err
 	return &CreatedJob, nil
 }
 
-func (ds *DataStorer) GetJobs(_ context.Context, _ []string, _ int, _ int) (*models.JobResults, error) {
+func (ds *DataStorer) GetJobs(_ context.Context, _ *models.JobFilter, _ []models.JobSort, _ []string, _ int, _ int) (*models.JobResults, error) {
 	if ds.InternalError {
 		return &models.JobResults{Items: []*models.Job{}}, InternalError
 	}
+	if ds.NotFound {
+		return &models.JobResults{Items: []*models.Job{}}, nil
+	}
 	return &models.JobResults{Items: []*models.Job{{ID: "34534543543"}}}, nil
 }
 
+func (ds *DataStorer) GetJobsAfter(_ context.Context, _ *models.JobFilter, _ *models.JobCursor, _ []string, _ int) (*models.JobResults, error) {
+	if ds.InternalError {
+		return &models.JobResults{Items: []*models.Job{}}, InternalError
+	}
+	if ds.NotFound {
+		return &models.JobResults{Items: []*models.Job{}}, nil
+	}
+	return &models.JobResults{Items: []*models.Job{{ID: "34534543543"}}}, nil
+}
+
+func (ds *DataStorer) GetJobFields(ctx context.Context, jobID string, _ []string) (*models.Job, error) {
+	return ds.GetJob(ctx, jobID)
+}
+
 func (ds *DataStorer) GetJob(_ context.Context, _ string) (*models.Job, error) {
 	if ds.InternalError {
 		return &models.Job{}, InternalError
@@ -45,7 +59,8 @@ func (ds *DataStorer) GetJob(_ context.Context, _ string) (*models.Job, error) {
 		return &models.Job{}, errs.ErrJobNotFound
 	}
 	return &models.Job{
-		ID: "34534543543",
+		ID:    "34534543543",
+		State: models.CreatedState,
 		Processed: []models.ProcessedInstances{
 			{
 				ID:             "54321",
@@ -56,6 +71,13 @@ func (ds *DataStorer) GetJob(_ context.Context, _ string) (*models.Job, error) {
 	}, nil
 }
 
+func (ds *DataStorer) GetJobByIdempotencyKey(_ context.Context, _ string) (*models.Job, error) {
+	if ds.InternalError {
+		return &models.Job{}, InternalError
+	}
+	return nil, errs.ErrJobNotFound
+}
+
 func (ds *DataStorer) AddInstance(_ context.Context, _ string) (string, error) {
 	if ds.NotFound {
 		return "", errs.ErrJobNotFound
@@ -66,7 +88,7 @@ func (ds *DataStorer) AddInstance(_ context.Context, _ string) (string, error) {
 	return "123", nil
 }
 
-func (ds *DataStorer) UpdateJob(_ context.Context, _ string, _ *models.Job) error {
+func (ds *DataStorer) UpdateJob(_ context.Context, _ string, _ *models.Job, _ string) error {
 	if ds.NotFound {
 		return errs.ErrJobNotFound
 	}
@@ -76,7 +98,7 @@ func (ds *DataStorer) UpdateJob(_ context.Context, _ string, _ *models.Job) erro
 	return nil
 }
 
-func (ds *DataStorer) UpdateJobState(_ context.Context, _ string, _ string) error {
+func (ds *DataStorer) RetryJob(_ context.Context, _ string, _ *models.Job) error {
 	if ds.NotFound {
 		return errs.ErrJobNotFound
 	}
@@ -86,7 +108,7 @@ func (ds *DataStorer) UpdateJobState(_ context.Context, _ string, _ string) erro
 	return nil
 }
 
-func (ds *DataStorer) AddUploadedFile(_ context.Context, _ string, _ *models.UploadedFile) error {
+func (ds *DataStorer) UpdateJobState(_ context.Context, _ string, _ string) error {
 	if ds.NotFound {
 		return errs.ErrJobNotFound
 	}
@@ -96,7 +118,7 @@ func (ds *DataStorer) AddUploadedFile(_ context.Context, _ string, _ *models.Upl
 	return nil
 }
 
-func (ds *DataStorer) UpdateProcessedInstance(_ context.Context, _ string, _ []models.ProcessedInstances) (err error) {
+func (ds *DataStorer) AddUploadedFile(_ context.Context, _ string, _ *models.UploadedFile, _ string) error {
 	if ds.NotFound {
 		return errs.ErrJobNotFound
 	}
@@ -106,25 +128,69 @@ func (ds *DataStorer) UpdateProcessedInstance(_ context.Context, _ string, _ []m
 	return nil
 }
 
-func (ds *DataStorer) Close(_ context.Context) error {
+func (ds *DataStorer) DeleteUploadedFile(_ context.Context, _, _, _ string) error {
+	if ds.NotFound {
+		return errs.ErrJobNotFound
+	}
+	if ds.InternalError {
+		return InternalError
+	}
 	return nil
 }
 
-func (ds *DataStorer) Checker(_ context.Context, _ *healthcheck.CheckState) error {
+func (ds *DataStorer) IncreaseProcessedInstance(ctx context.Context, jobID, instanceID, _, _ string) ([]models.ProcessedInstances, error) {
+	job, err := ds.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range job.Processed {
+		if job.Processed[i].ID == instanceID {
+			job.Processed[i].ProcessedCount++
+			return job.Processed, nil
+		}
+	}
+	return nil, errs.ErrInvalidInstanceID
+}
+
+func (ds *DataStorer) AddOutboxMessage(_ context.Context, _ *models.OutboxMessage) error {
+	if ds.InternalError {
+		return InternalError
+	}
 	return nil
 }
 
-func (ds *DataStorer) AcquireInstanceLock(_ context.Context, _ string) (lockID string, err error) {
-	if ds.IsLocked {
-		return "", errors.New("already locked")
+func (ds *DataStorer) GetPendingOutboxMessages(_ context.Context, _, _ time.Time, _ int) ([]*models.OutboxMessage, error) {
+	if ds.InternalError {
+		return nil, InternalError
+	}
+	return []*models.OutboxMessage{}, nil
+}
+
+func (ds *DataStorer) ClaimOutboxMessage(_ context.Context, _ string, _ time.Time) (bool, error) {
+	if ds.InternalError {
+		return false, InternalError
+	}
+	return true, nil
+}
+
+func (ds *DataStorer) UpdateOutboxMessageState(_ context.Context, _, _ string) error {
+	if ds.InternalError {
+		return InternalError
 	}
-	ds.IsLocked = true
-	ds.HasBeenLocked = true
-	return testLockID, nil
+	return nil
 }
 
-func (ds *DataStorer) UnlockInstance(_ context.Context, lockID string) {
-	if lockID == testLockID {
-		ds.IsLocked = false
+func (ds *DataStorer) IncreaseOutboxMessageAttempts(_ context.Context, _ string) error {
+	if ds.InternalError {
+		return InternalError
 	}
+	return nil
+}
+
+func (ds *DataStorer) Close(_ context.Context) error {
+	return nil
+}
+
+func (ds *DataStorer) Checker(_ context.Context, _ *healthcheck.CheckState) error {
+	return nil
 }
@@ -21,6 +21,20 @@ var ImportV4FileEvent = `{
   ]
 }`
 
+// CantabularDatasetInstanceStartedEvent extends the cantabular-dataset-instance-started schema of dp-import
+// with the dataset ID of the instance. The field is appended, so consumers of the original schema can still read it.
+var CantabularDatasetInstanceStartedEvent = `{
+  "type": "record",
+  "name": "cantabular-dataset-instance-started",
+  "fields": [
+    {"name": "recipe_id", "type": "string"},
+    {"name": "instance_id", "type": "string"},
+    {"name": "job_id", "type": "string"},
+    {"name": "cantabular_type", "type": "string"},
+    {"name": "dataset_id", "type": "string", "default": ""}
+  ]
+}`
+
 var DataBaker *avro.Schema = &avro.Schema{
 	Definition: DataBakerEvent,
 }
@@ -28,3 +42,7 @@ var DataBaker *avro.Schema = &avro.Schema{
 var ImportV4File *avro.Schema = &avro.Schema{
 	Definition: ImportV4FileEvent,
 }
+
+var CantabularDatasetInstanceStarted *avro.Schema = &avro.Schema{
+	Definition: CantabularDatasetInstanceStartedEvent,
+}
@@ -18,4 +18,14 @@ func TestAvroSchema(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(results.JobID, ShouldEqual, message.JobID)
 	})
+
+	Convey("When marshalling a CantabularDatasetInstanceStarted message, no errors are returned", t, func() {
+		message := models.CantabularDatasetInstanceStartedEvent{RecipeID: "456", InstanceID: "789", JobID: "123", CantabularType: "cantabular_table", DatasetID: "dataset1"}
+		bytes, avroError := CantabularDatasetInstanceStarted.Marshal(message)
+		So(avroError, ShouldBeNil)
+		var results models.CantabularDatasetInstanceStartedEvent
+		err := CantabularDatasetInstanceStarted.Unmarshal(bytes, &results)
+		So(err, ShouldBeNil)
+		So(results, ShouldResemble, message)
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,17 +32,16 @@ import (
 
 // Service contains all the configs, server and clients to run the Dataset API
 type Service struct {
-	cfg                                      *config.Configuration
-	mongoDataStore                           datastore.DataStorer
-	dataBakerProducer                        kafka.IProducer
-	inputFileAvailableProducer               kafka.IProducer
-	cantabularDatasetInstanceStartedProducer kafka.IProducer
-	server                                   HTTPServer
-	healthCheck                              HealthChecker
-	importAPI                                *api.ImportAPI
-	identityClient                           *clientsidentity.Client
-	datasetAPIClient                         job.DatasetAPIClient
-	recipeAPIClient                          job.RecipeAPIClient
+	cfg              *config.Configuration
+	mongoDataStore   datastore.DataStorer
+	kafkaProducers   map[string]kafka.IProducer
+	server           HTTPServer
+	healthCheck      HealthChecker
+	importAPI        *api.ImportAPI
+	identityClient   *clientsidentity.Client
+	datasetAPIClient job.DatasetAPIClient
+	recipeAPIClient  job.RecipeAPIClient
+	outboxRelay      *job.OutboxRelay
 }
 
 // getMongoDataStore creates a mongoDB connection
@@ -98,25 +98,16 @@ func (svc *Service) Init(ctx context.Context, cfg *config.Configuration, buildTi
 		return err
 	}
 
-	// Get data baker kafka producer
-	svc.dataBakerProducer, err = getKafkaProducer(ctx, &svc.cfg.KafkaConfig, svc.cfg.DatabakerImportTopic)
-	if err != nil {
-		log.Fatal(ctx, "databaker kafka producer error", err)
-		return err
-	}
-
-	// Get input file available kafka producer
-	svc.inputFileAvailableProducer, err = getKafkaProducer(ctx, &svc.cfg.KafkaConfig, svc.cfg.InputFileAvailableTopic)
-	if err != nil {
-		log.Fatal(ctx, "direct kafka producer error", err)
-		return err
-	}
-
-	// Get Cantabular Dataset Instance Started kafka producer
-	svc.cantabularDatasetInstanceStartedProducer, err = getKafkaProducer(ctx, &svc.cfg.KafkaConfig, svc.cfg.CantabularDatasetInstanceStartedTopic)
-	if err != nil {
-		log.Fatal(ctx, "cantabular dataset instance started kafka producer error", err)
-		return err
+	// Get a kafka producer for each topic that the supported formats are dispatched to
+	formats := importqueue.NewDefaultRegistry(&svc.cfg.KafkaConfig)
+	svc.kafkaProducers = map[string]kafka.IProducer{}
+	for _, topic := range formats.Topics() {
+		producer, err := getKafkaProducer(ctx, &svc.cfg.KafkaConfig, topic)
+		if err != nil {
+			log.Fatal(ctx, "kafka producer error", err, log.Data{"topic": topic})
+			return err
+		}
+		svc.kafkaProducers[topic] = producer
 	}
 
 	// Create Identity Client
@@ -144,13 +135,14 @@ func (svc *Service) Init(ctx context.Context, cfg *config.Configuration, buildTi
 
 	// Create API with job service
 	urlBuilder := url.NewBuilder(svc.cfg.Host, svc.cfg.DatasetAPIURL)
-	jobQueue := importqueue.CreateImportQueue(
-		svc.dataBakerProducer.Channels().Output,
-		svc.inputFileAvailableProducer.Channels().Output,
-		svc.cantabularDatasetInstanceStartedProducer.Channels().Output,
-	)
+	queues := map[string]chan []byte{}
+	for topic, producer := range svc.kafkaProducers {
+		queues[topic] = producer.Channels().Output
+	}
+	jobQueue := importqueue.CreateImportQueue(formats, queues)
 	jobService := job.NewService(svc.mongoDataStore, jobQueue, svc.cfg.DatasetAPIURL, svc.datasetAPIClient, svc.recipeAPIClient, urlBuilder, svc.cfg.ServiceAuthToken)
 	svc.importAPI = api.Setup(r, svc.mongoDataStore, jobService, cfg)
+	svc.outboxRelay = job.NewOutboxRelay(jobService, svc.cfg.OutboxRelayInterval)
 	return nil
 }
 
@@ -158,13 +150,16 @@ func (svc *Service) Init(ctx context.Context, cfg *config.Configuration, buildTi
 func (svc *Service) Start(ctx context.Context, svcErrors chan error) {
 
 	// Start kafka logging
-	svc.dataBakerProducer.Channels().LogErrors(ctx, "error received from kafka data baker producer, topic: "+svc.cfg.DatabakerImportTopic)
-	svc.inputFileAvailableProducer.Channels().LogErrors(ctx, "error received from kafka input file available producer, topic: "+svc.cfg.InputFileAvailableTopic)
-	svc.cantabularDatasetInstanceStartedProducer.Channels().LogErrors(ctx, "error recieved from kafka cantabular dataset instance started producer, topic: "+svc.cfg.CantabularDatasetInstanceStartedTopic)
+	for _, topic := range svc.kafkaTopics() {
+		svc.kafkaProducers[topic].Channels().LogErrors(ctx, "error received from kafka producer, topic: "+topic)
+	}
 
 	// Start healthcheck
 	svc.healthCheck.Start(ctx)
 
+	// Start sending the import events that are pending in the outbox
+	svc.outboxRelay.Start(ctx)
+
 	// Run the http server in a new go-routine
 	go func() {
 		log.Info(ctx, "Starting api...")
@@ -208,6 +203,15 @@ func (svc *Service) Close(ctx context.Context) error {
 			}
 		}
 
+		// stop sending pending import events, as the relay depends on MongoDB and kafka
+		if svc.outboxRelay != nil {
+			log.Info(ctx, "closing outbox relay")
+			if err := svc.outboxRelay.Close(ctx); err != nil {
+				log.Error(ctx, "unable to close outbox relay", err)
+				hasShutdownError = true
+			}
+		}
+
 		// Close MongoDB (if it exists)
 		if svc.mongoDataStore != nil {
 			log.Info(ctx, "closing mongo data store")
@@ -217,29 +221,11 @@ func (svc *Service) Close(ctx context.Context) error {
 			}
 		}
 
-		// Close Data Baker Kafka Producer (it if exists)
-		if svc.dataBakerProducer != nil {
-			log.Info(ctx, "closing data baker producer")
-			if err := svc.dataBakerProducer.Close(ctx); err != nil {
-				log.Error(ctx, "unable to close data baker producer", err)
-				hasShutdownError = true
-			}
-		}
-
-		// Close Direct Kafka Producer (if it exists)
-		if svc.inputFileAvailableProducer != nil {
-			log.Info(ctx, "closing direct producer")
-			if err := svc.inputFileAvailableProducer.Close(ctx); err != nil {
-				log.Error(ctx, "unable to close direct producer", err)
-				hasShutdownError = true
-			}
-		}
-
-		// Close Cantabular Kafka Producer (if it exists)
-		if svc.cantabularDatasetInstanceStartedProducer != nil {
-			log.Info(ctx, "closing cantabular dataset instance started producer")
-			if err := svc.cantabularDatasetInstanceStartedProducer.Close(ctx); err != nil {
-				log.Error(ctx, "unable to close cantabular dataset instance started producer", err)
+		// Close Kafka Producers (if they exist)
+		for _, topic := range svc.kafkaTopics() {
+			log.Info(ctx, "closing kafka producer", log.Data{"topic": topic})
+			if err := svc.kafkaProducers[topic].Close(ctx); err != nil {
+				log.Error(ctx, "unable to close kafka producer", err, log.Data{"topic": topic})
 				hasShutdownError = true
 			}
 		}
@@ -294,9 +280,9 @@ func (svc *Service) registerCheckers(ctx context.Context) (err error) {
 		}
 	}
 
-	registerChecker("Kafka Data Baker Producer", svc.dataBakerProducer)
-	registerChecker("Kafka Input File Available Producer", svc.inputFileAvailableProducer)
-	registerChecker("Kafka Cantabular Dataset Instance Started Producer", svc.cantabularDatasetInstanceStartedProducer)
+	for _, topic := range svc.kafkaTopics() {
+		registerChecker("Kafka Producer "+topic, svc.kafkaProducers[topic])
+	}
 	registerChecker("Zebedee", svc.identityClient)
 	registerChecker("Mongo DB", svc.mongoDataStore)
 	registerChecker("Dataset API", svc.datasetAPIClient)
@@ -307,3 +293,14 @@ func (svc *Service) registerCheckers(ctx context.Context) (err error) {
 	}
 	return nil
 }
+
+// kafkaTopics returns the sorted list of topics with a kafka producer
+func (svc *Service) kafkaTopics() []string {
+	topics := []string{}
+	for topic := range svc.kafkaProducers {
+		topics = append(topics, topic)
+	}
+
+	sort.Strings(topics)
+	return topics
+}
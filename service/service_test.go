@@ -12,6 +12,7 @@ import (
 	"github.com/ONSdigital/dp-import-api/config"
 	"github.com/ONSdigital/dp-import-api/datastore"
 	dsmock "github.com/ONSdigital/dp-import-api/datastore/mock"
+	"github.com/ONSdigital/dp-import-api/job"
 	"github.com/ONSdigital/dp-import-api/service/mock"
 	kafka "github.com/ONSdigital/dp-kafka/v2"
 	"github.com/ONSdigital/dp-kafka/v2/kafkatest"
@@ -59,9 +60,6 @@ func TestInit(t *testing.T) {
 		getKafkaProducer = func(ctx context.Context, cfg *config.KafkaConfig, topic string) (kafka.IProducer, error) {
 			return kafkaMock, nil
 		}
-		getKafkaProducer = func(ctx context.Context, cfg *config.KafkaConfig, topic string) (kafka.IProducer, error) {
-			return kafkaMock, nil
-		}
 
 		hcMock := &mock.HealthCheckerMock{
 			AddCheckFunc: func(name string, checker healthcheck.Checker) error { return nil },
@@ -86,9 +84,7 @@ func TestInit(t *testing.T) {
 				err := svc.Init(ctx, cfg, testBuildTime, testGitCommit, testVersion)
 				So(err, ShouldResemble, errMongo)
 				So(svc.mongoDataStore, ShouldBeNil)
-				So(svc.dataBakerProducer, ShouldBeNil)
-				So(svc.inputFileAvailableProducer, ShouldBeNil)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldBeNil)
+				So(svc.kafkaProducers, ShouldBeEmpty)
 				So(svc.healthCheck, ShouldBeNil)
 				So(svc.server, ShouldBeNil)
 			})
@@ -106,9 +102,9 @@ func TestInit(t *testing.T) {
 				err := svc.Init(ctx, cfg, testBuildTime, testGitCommit, testVersion)
 				So(err, ShouldResemble, errKafka)
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldBeNil)
-				So(svc.inputFileAvailableProducer, ShouldBeNil)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldBeNil)
+				So(svc.kafkaProducers, ShouldResemble, map[string]kafka.IProducer{
+					cfg.CantabularDatasetInstanceStartedTopic: kafkaMock,
+				})
 				So(svc.healthCheck, ShouldBeNil)
 				So(svc.server, ShouldBeNil)
 			})
@@ -126,9 +122,10 @@ func TestInit(t *testing.T) {
 				err := svc.Init(ctx, cfg, testBuildTime, testGitCommit, testVersion)
 				So(err, ShouldResemble, errKafka)
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldResemble, kafkaMock)
-				So(svc.inputFileAvailableProducer, ShouldBeNil)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldBeNil)
+				So(svc.kafkaProducers, ShouldResemble, map[string]kafka.IProducer{
+					cfg.CantabularDatasetInstanceStartedTopic: kafkaMock,
+					cfg.DatabakerImportTopic:                  kafkaMock,
+				})
 				So(svc.healthCheck, ShouldBeNil)
 				So(svc.server, ShouldBeNil)
 			})
@@ -146,10 +143,7 @@ func TestInit(t *testing.T) {
 				err := svc.Init(ctx, cfg, testBuildTime, testGitCommit, testVersion)
 				So(err, ShouldResemble, errKafka)
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldResemble, kafkaMock)
-				So(svc.inputFileAvailableProducer, ShouldResemble, kafkaMock)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldBeNil)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldBeNil)
+				So(svc.kafkaProducers, ShouldBeEmpty)
 				So(svc.healthCheck, ShouldBeNil)
 				So(svc.server, ShouldBeNil)
 			})
@@ -163,9 +157,11 @@ func TestInit(t *testing.T) {
 				So(err, ShouldNotBeNil)
 				So(err.Error(), ShouldResemble, "failed to parse build time")
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldResemble, kafkaMock)
-				So(svc.inputFileAvailableProducer, ShouldResemble, kafkaMock)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldResemble, kafkaMock)
+				So(svc.kafkaProducers, ShouldResemble, map[string]kafka.IProducer{
+					cfg.CantabularDatasetInstanceStartedTopic: kafkaMock,
+					cfg.DatabakerImportTopic:                  kafkaMock,
+					cfg.InputFileAvailableTopic:               kafkaMock,
+				})
 				So(svc.healthCheck, ShouldBeNil)
 				So(svc.server, ShouldBeNil)
 			})
@@ -179,17 +175,19 @@ func TestInit(t *testing.T) {
 				So(err, ShouldNotBeNil)
 				So(err.Error(), ShouldResemble, "unable to register checkers: Error(s) registering checkers for healthcheck")
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldResemble, kafkaMock)
-				So(svc.inputFileAvailableProducer, ShouldResemble, kafkaMock)
-				So(svc.cantabularDatasetInstanceStartedProducer, ShouldResemble, kafkaMock)
+				So(svc.kafkaProducers, ShouldResemble, map[string]kafka.IProducer{
+					cfg.CantabularDatasetInstanceStartedTopic: kafkaMock,
+					cfg.DatabakerImportTopic:                  kafkaMock,
+					cfg.InputFileAvailableTopic:               kafkaMock,
+				})
 				So(svc.healthCheck, ShouldResemble, hcMock)
 				So(svc.server, ShouldBeNil)
 
 				Convey("But all checks try to register", func() {
 					So(len(hcMock.AddCheckCalls()), ShouldEqual, 7)
-					So(hcMock.AddCheckCalls()[0].Name, ShouldResemble, "Kafka Data Baker Producer")
-					So(hcMock.AddCheckCalls()[1].Name, ShouldResemble, "Kafka Input File Available Producer")
-					So(hcMock.AddCheckCalls()[2].Name, ShouldResemble, "Kafka Cantabular Dataset Instance Started Producer")
+					So(hcMock.AddCheckCalls()[0].Name, ShouldResemble, "Kafka Producer cantabular-dataset-instance-started")
+					So(hcMock.AddCheckCalls()[1].Name, ShouldResemble, "Kafka Producer data-bake-job-available")
+					So(hcMock.AddCheckCalls()[2].Name, ShouldResemble, "Kafka Producer input-file-available")
 					So(hcMock.AddCheckCalls()[3].Name, ShouldResemble, "Zebedee")
 					So(hcMock.AddCheckCalls()[4].Name, ShouldResemble, "Mongo DB")
 					So(hcMock.AddCheckCalls()[5].Name, ShouldResemble, "Dataset API")
@@ -204,16 +202,20 @@ func TestInit(t *testing.T) {
 				err := svc.Init(ctx, cfg, testBuildTime, testGitCommit, testVersion)
 				So(err, ShouldBeNil)
 				So(svc.mongoDataStore, ShouldResemble, datastoreMock)
-				So(svc.dataBakerProducer, ShouldResemble, kafkaMock)
-				So(svc.inputFileAvailableProducer, ShouldResemble, kafkaMock)
+				So(svc.kafkaProducers, ShouldResemble, map[string]kafka.IProducer{
+					cfg.CantabularDatasetInstanceStartedTopic: kafkaMock,
+					cfg.DatabakerImportTopic:                  kafkaMock,
+					cfg.InputFileAvailableTopic:               kafkaMock,
+				})
 				So(svc.healthCheck, ShouldResemble, hcMock)
 				So(svc.server, ShouldResemble, serverMock)
+				So(svc.outboxRelay, ShouldNotBeNil)
 
 				Convey("And all checks are registered", func() {
 					So(len(hcMock.AddCheckCalls()), ShouldEqual, 7)
-					So(hcMock.AddCheckCalls()[0].Name, ShouldResemble, "Kafka Data Baker Producer")
-					So(hcMock.AddCheckCalls()[1].Name, ShouldResemble, "Kafka Input File Available Producer")
-					So(hcMock.AddCheckCalls()[2].Name, ShouldResemble, "Kafka Cantabular Dataset Instance Started Producer")
+					So(hcMock.AddCheckCalls()[0].Name, ShouldResemble, "Kafka Producer cantabular-dataset-instance-started")
+					So(hcMock.AddCheckCalls()[1].Name, ShouldResemble, "Kafka Producer data-bake-job-available")
+					So(hcMock.AddCheckCalls()[2].Name, ShouldResemble, "Kafka Producer input-file-available")
 					So(hcMock.AddCheckCalls()[3].Name, ShouldResemble, "Zebedee")
 					So(hcMock.AddCheckCalls()[4].Name, ShouldResemble, "Mongo DB")
 					So(hcMock.AddCheckCalls()[5].Name, ShouldResemble, "Dataset API")
@@ -257,12 +259,15 @@ func TestStart(t *testing.T) {
 		serverMock := &mock.HTTPServerMock{}
 
 		svc := &Service{
-			cfg:                                      cfg,
-			dataBakerProducer:                        kafkaProducerBaker,
-			inputFileAvailableProducer:               kafkaProducerDirect,
-			cantabularDatasetInstanceStartedProducer: kafkaProducerCantabular,
-			healthCheck:                              hcMock,
-			server:                                   serverMock,
+			cfg: cfg,
+			kafkaProducers: map[string]kafka.IProducer{
+				cfg.DatabakerImportTopic:                  kafkaProducerBaker,
+				cfg.InputFileAvailableTopic:               kafkaProducerDirect,
+				cfg.CantabularDatasetInstanceStartedTopic: kafkaProducerCantabular,
+			},
+			healthCheck: hcMock,
+			server:      serverMock,
+			outboxRelay: job.NewOutboxRelay(&job.Service{}, cfg.OutboxRelayInterval),
 		}
 
 		Convey("When a service with a successful HTTP server is started", func() {
@@ -370,14 +375,18 @@ func TestClose(t *testing.T) {
 		})
 
 		svc := Service{
-			cfg:                                      cfg,
-			healthCheck:                              hcMock,
-			mongoDataStore:                           mongoMock,
-			dataBakerProducer:                        dataBakerKafkaProducerMock,
-			inputFileAvailableProducer:               inputFileProducerAvailableKafkaProducer,
-			cantabularDatasetInstanceStartedProducer: cantabularKafkaProducer,
-			server:                                   serverMock,
+			cfg:            cfg,
+			healthCheck:    hcMock,
+			mongoDataStore: mongoMock,
+			kafkaProducers: map[string]kafka.IProducer{
+				cfg.DatabakerImportTopic:                  dataBakerKafkaProducerMock,
+				cfg.InputFileAvailableTopic:               inputFileProducerAvailableKafkaProducer,
+				cfg.CantabularDatasetInstanceStartedTopic: cantabularKafkaProducer,
+			},
+			server:      serverMock,
+			outboxRelay: job.NewOutboxRelay(&job.Service{}, cfg.OutboxRelayInterval),
 		}
+		svc.outboxRelay.Start(ctx)
 
 		Convey("Closing the service results in all the initialised dependencies being closed in the expected order", func() {
 			err := svc.Close(context.Background())
@@ -19,6 +19,14 @@ func (builder Builder) GetJobURL(jobID string) string {
 	return builder.importAPIHost + "/jobs/" + jobID
 }
 
+// GetJobsURL returns the url to get the list of jobs matching the given encoded query.
+func (builder Builder) GetJobsURL(rawQuery string) string {
+	if rawQuery == "" {
+		return builder.importAPIHost + "/jobs"
+	}
+	return builder.importAPIHost + "/jobs?" + rawQuery
+}
+
 // GetInstanceURL
 func (builder Builder) GetInstanceURL(instanceID string) string {
 	return builder.datasetAPIHost + "/instances/" + instanceID
@@ -46,3 +46,30 @@ func TestBuilder_GetJobURL(t *testing.T) {
 		})
 	})
 }
+
+func TestBuilder_GetJobsURL(t *testing.T) {
+
+	Convey("Given an encoded query", t, func() {
+
+		rawQuery := "cursor=abc&state=created"
+
+		Convey("When the jobs URL is requested", func() {
+
+			builtURL := builder.GetJobsURL(rawQuery)
+
+			Convey("Then the expected URL is returned", func() {
+				expectedURL := importAPIHost + "/jobs?" + rawQuery
+				So(builtURL, ShouldEqual, expectedURL)
+			})
+		})
+
+		Convey("When the jobs URL is requested without a query", func() {
+
+			builtURL := builder.GetJobsURL("")
+
+			Convey("Then the URL of the jobs is returned", func() {
+				So(builtURL, ShouldEqual, importAPIHost+"/jobs")
+			})
+		})
+	})
+}